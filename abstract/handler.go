@@ -1,7 +1,10 @@
 package abstract
 
 import (
+	"context"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/vizucode/gokit/types"
 	"google.golang.org/grpc"
 )
@@ -16,6 +19,12 @@ type GRPCHandler interface {
 	Register(srv *grpc.Server)
 }
 
+// GatewayHandler abstraction for grpc-gateway registration, implemented by GRPCHandler
+// which expose its services over rest, e.g. calling generated RegisterXxxHandler
+type GatewayHandler interface {
+	RegisterGateway(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+}
+
 // BrokerHandler abstraction for worker handler
 type BrokerHandler interface {
 	Register(broker *types.BrokerHandlerGroup)
@@ -0,0 +1,68 @@
+package abstract
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RealtimeHandler abstraction for websocket and server-sent events handler
+type RealtimeHandler interface {
+	Realtime(r RealtimeRouter)
+}
+
+// WebSocketHandlerFunc handle websocket connection, the connection is closed when the handler return
+type WebSocketHandlerFunc func(conn WebSocketConn) error
+
+// SSEHandlerFunc handle server-sent events stream, the handler should return when stream.Context() is done
+type SSEHandlerFunc func(stream SSEStream) error
+
+// RealtimeRouter register realtime handlers, middlewares are executed before the connection is upgraded or streamed
+type RealtimeRouter interface {
+	// WebSocket register websocket handler on path
+	WebSocket(path string, handler WebSocketHandlerFunc, middlewares ...fiber.Handler)
+	// SSE register server-sent events handler on path
+	SSE(path string, handler SSEHandlerFunc, middlewares ...fiber.Handler)
+}
+
+// WebSocketConn websocket connection with logging and tracing context
+type WebSocketConn interface {
+	// Context logging context of the connection, done when connection closed or server shutting down
+	Context() context.Context
+	// Params get route params captured on upgrade
+	Params(key string, defaultValue ...string) string
+	// Query get query string captured on upgrade
+	Query(key string, defaultValue ...string) string
+	// Headers get request header captured on upgrade
+	Headers(key string, defaultValue ...string) string
+	// Locals get locals set by previous middleware
+	Locals(key string) interface{}
+
+	// ReadMessage read next message from client
+	ReadMessage() (messageType int, data []byte, err error)
+	// ReadJSON read next message from client and decode it into v
+	ReadJSON(v interface{}) error
+	// WriteMessage write message to client, safe for concurrent use
+	WriteMessage(messageType int, data []byte) error
+	// WriteJSON write v as json text message to client
+	WriteJSON(v interface{}) error
+	// CloseWithReason send close frame to client
+	CloseWithReason(code int, reason string) error
+}
+
+// SSEStream server-sent events stream with logging and tracing context
+type SSEStream interface {
+	// Context logging context of the stream, done when client is gone or server shutting down
+	Context() context.Context
+	// Params get route params
+	Params(key string) string
+	// Query get query string
+	Query(key string) string
+	// Headers get request header
+	Headers(key string) string
+
+	// Send write event to client, empty event is sent as default message event. Safe for concurrent use
+	Send(event string, data []byte) error
+	// SendJSON write v as json data of event
+	SendJSON(event string, v interface{}) error
+}
@@ -0,0 +1,118 @@
+package grpcc
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vizucode/gokit/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Factory managed grpc client connections, cached per target and closed on shutdown
+type Factory struct {
+	opt []OptionFunc
+
+	mu     sync.Mutex
+	conns  map[string]*grpc.ClientConn
+	closed bool
+}
+
+// New create client connection factory, options are applied to every connection
+func New(opts ...OptionFunc) *Factory {
+	return &Factory{
+		opt:   opts,
+		conns: make(map[string]*grpc.ClientConn),
+	}
+}
+
+// Conn get cached connection of target or create it, options are applied after options of factory
+// and only used when the connection is created
+func (f *Factory) Conn(target string, opts ...OptionFunc) (*grpc.ClientConn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, errors.New("grpc client: factory is closed")
+	}
+
+	if conn, ok := f.conns[target]; ok {
+		return conn, nil
+	}
+
+	o := defaultOption()
+	for _, opt := range append(append([]OptionFunc{}, f.opt...), opts...) {
+		opt(&o)
+	}
+
+	conn, err := Dial(target, o)
+	if err != nil {
+		return nil, err
+	}
+
+	f.conns[target] = conn
+	return conn, nil
+}
+
+// MustConn get connection of target, panic when failed
+func (f *Factory) MustConn(target string, opts ...OptionFunc) *grpc.ClientConn {
+	conn, err := f.Conn(target, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return conn
+}
+
+// Close close all connections
+func (f *Factory) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+
+	var errs []error
+	for target, conn := range f.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("grpc client %s: %w", target, err))
+		}
+		delete(f.conns, target)
+	}
+
+	logger.RedBold("Closing GRPC Clients")
+	return errors.Join(errs...)
+}
+
+// Dial create client connection of target with logging, tracing, metrics and request id propagation
+func Dial(target string, o option) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if o.secureTLS != nil {
+		creds = credentials.NewTLS(o.secureTLS)
+	}
+
+	i := &interceptor{target: target, serviceName: o.serviceName, opt: o}
+
+	// interceptors order: errorkit conversion, default deadline, logging, metadata, user interceptors
+	unary := []grpc.UnaryClientInterceptor{i.unaryErrorkit, i.unaryDeadline, i.unaryTracer, i.unaryMetadata}
+	stream := []grpc.StreamClientInterceptor{i.streamErrorkit, i.streamTracer, i.streamMetadata}
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(o.keepalive),
+		grpc.WithChainUnaryInterceptor(append(unary, o.unaryInterceptors...)...),
+		grpc.WithChainStreamInterceptor(append(stream, o.streamInterceptors...)...),
+	}
+
+	if o.serviceConfig != "" {
+		dialOptions = append(dialOptions, grpc.WithDefaultServiceConfig(o.serviceConfig))
+	}
+
+	conn, err := grpc.NewClient(target, append(dialOptions, o.dialOptions...)...)
+	if err != nil {
+		return nil, fmt.Errorf("grpc client %s: %w", target, err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,186 @@
+package grpcc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vizucode/gokit/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// echoService unary, client streaming and server streaming methods of wrapperspb.StringValue
+var echoService = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Unary",
+		Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(wrapperspb.StringValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			return in, nil
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Collect",
+		ClientStreams: true,
+		Handler: func(_ interface{}, stream grpc.ServerStream) error {
+			var joined string
+			for {
+				in := new(wrapperspb.StringValue)
+				if err := stream.RecvMsg(in); errors.Is(err, io.EOF) {
+					return stream.SendMsg(wrapperspb.String(joined))
+				} else if err != nil {
+					return err
+				}
+				joined += in.GetValue()
+			}
+		},
+	}, {
+		StreamName:    "Repeat",
+		ServerStreams: true,
+		Handler: func(_ interface{}, stream grpc.ServerStream) error {
+			in := new(wrapperspb.StringValue)
+			if err := stream.RecvMsg(in); err != nil {
+				return err
+			}
+			for i := 0; i < 3; i++ {
+				if err := stream.SendMsg(in); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}},
+}
+
+func dialEcho(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	srv.RegisterService(&echoService, nil)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	f := New(SetDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})))
+	t.Cleanup(func() { _ = f.Close() })
+
+	return f.MustConn("passthrough:///bufnet")
+}
+
+// thirdParty logged calls of context, waiting the stream goroutine which may finish late
+func thirdParty(t *testing.T, lock *logger.Locker) logger.ThirdParty {
+	t.Helper()
+
+	// give the context watcher of the stream a chance to finish the call with a wrong status
+	time.Sleep(20 * time.Millisecond)
+
+	v, ok := lock.Load(logger.Flags("ThirdParties"))
+	if !ok {
+		t.Fatal("call is not logged")
+	}
+
+	calls := v.([]logger.ThirdParty)
+	if len(calls) != 1 {
+		t.Fatalf("call is logged %d times", len(calls))
+	}
+
+	return calls[0]
+}
+
+func TestTracerStatus(t *testing.T) {
+	conn := dialEcho(t)
+
+	tests := map[string]func(ctx context.Context) error{
+		"unary": func(ctx context.Context) error {
+			return conn.Invoke(ctx, "/test.Echo/Unary", wrapperspb.String("a"), new(wrapperspb.StringValue))
+		},
+		"client streaming": func(ctx context.Context) error {
+			cs, err := conn.NewStream(ctx, &echoService.Streams[0], "/test.Echo/Collect")
+			if err != nil {
+				return err
+			}
+			for _, v := range []string{"a", "b"} {
+				if err = cs.SendMsg(wrapperspb.String(v)); err != nil {
+					return err
+				}
+			}
+			if err = cs.CloseSend(); err != nil {
+				return err
+			}
+
+			out := new(wrapperspb.StringValue)
+			if err = cs.RecvMsg(out); err != nil {
+				return err
+			}
+			if out.GetValue() != "ab" {
+				return errors.New("unexpected response " + out.GetValue())
+			}
+			return nil
+		},
+		"server streaming": func(ctx context.Context) error {
+			cs, err := conn.NewStream(ctx, &echoService.Streams[1], "/test.Echo/Repeat")
+			if err != nil {
+				return err
+			}
+			if err = cs.SendMsg(wrapperspb.String("a")); err != nil {
+				return err
+			}
+			if err = cs.CloseSend(); err != nil {
+				return err
+			}
+
+			for {
+				if err = cs.RecvMsg(new(wrapperspb.StringValue)); errors.Is(err, io.EOF) {
+					return nil
+				} else if err != nil {
+					return err
+				}
+			}
+		},
+	}
+
+	for name, call := range tests {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				lock := new(logger.Locker)
+				ctx := context.WithValue(context.Background(), logger.LogKey, lock)
+
+				if err := call(ctx); err != nil {
+					t.Fatal(err)
+				}
+
+				if tp := thirdParty(t, lock); tp.StatusCode != http.StatusOK {
+					t.Fatalf("status code %d, response %q", tp.StatusCode, tp.Response)
+				}
+			}
+		})
+	}
+}
+
+func TestTracerCancelled(t *testing.T) {
+	conn := dialEcho(t)
+
+	lock := new(logger.Locker)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), logger.LogKey, lock))
+
+	if _, err := conn.NewStream(ctx, &echoService.Streams[1], "/test.Echo/Repeat"); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	if tp := thirdParty(t, lock); tp.StatusCode == http.StatusOK {
+		t.Fatal("cancelled stream is logged as success")
+	}
+}
@@ -0,0 +1,307 @@
+package grpcc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/tracer"
+	"github.com/vizucode/gokit/utils/errorkit"
+	"github.com/vizucode/gokit/utils/monitoring"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// HeaderRequestId metadata key of request id sent to server
+const HeaderRequestId = "x-request-id"
+
+// interceptor client interceptors of one connection
+type interceptor struct {
+	target      string
+	serviceName string
+	opt         option
+}
+
+// unaryErrorkit convert status received from server into errorkit error
+func (i *interceptor) unaryErrorkit(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if err != nil && i.opt.errorkit {
+		return errorkit.FromStatus(err)
+	}
+
+	return err
+}
+
+// unaryDeadline set default deadline when context has no deadline
+func (i *interceptor) unaryDeadline(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if _, ok := ctx.Deadline(); !ok && i.opt.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.opt.timeout)
+		defer cancel()
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// unaryTracer log, trace and record metrics of outgoing call
+func (i *interceptor) unaryTracer(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+	start := time.Now()
+
+	trace, ctx := tracer.StartTraceWithContext(ctx, fmt.Sprintf("GRPCRepository:%s", method))
+	tp := logger.ThirdParty{
+		ServiceTarget: method,
+		URL:           i.target,
+		RequestBody:   i.opt.redactor.String(req),
+		Method:        http.MethodPost,
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = status.Errorf(codes.Internal, "%v", r)
+		}
+
+		sc := errorkit.HTTPFromCode(status.Code(err))
+		if err != nil {
+			trace.SetError(err)
+			tp.Response = err.Error()
+		} else {
+			tp.Response = i.opt.redactor.String(reply)
+			trace.SetTag("response_body", tp.Response)
+		}
+
+		end := time.Since(start)
+		tp.StatusCode = sc
+		tp.ExecTime = end.Seconds()
+		tp.Store(ctx)
+
+		trace.SetTag("status_code", sc)
+		trace.Finish()
+
+		monitoring.PrometheusRecord(sc, tp.Method, method, i.serviceName, end)
+	}()
+
+	trace.SetTag("trace_id", tracer.GetTraceID(ctx))
+	trace.SetTag("url", tp.URL)
+	trace.SetTag("target", tp.ServiceTarget)
+	trace.SetTag("request_body", tp.RequestBody)
+
+	err = invoker(ctx, method, req, reply, cc, opts...)
+	return
+}
+
+// unaryMetadata send request id and trace context to server
+func (i *interceptor) unaryMetadata(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoingMetadata(ctx), method, req, reply, cc, opts...)
+}
+
+// streamErrorkit convert status received from server into errorkit error
+func (i *interceptor) streamErrorkit(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if !i.opt.errorkit {
+		return cs, err
+	}
+	if err != nil {
+		return nil, errorkit.FromStatus(err)
+	}
+
+	return &errorkitStream{ClientStream: cs}, nil
+}
+
+// streamTracer log, trace and record metrics of outgoing stream, finished when the stream ends
+func (i *interceptor) streamTracer(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	trace, ctx := tracer.StartTraceWithContext(ctx, fmt.Sprintf("GRPCRepository:%s", method))
+	trace.SetTag("trace_id", tracer.GetTraceID(ctx))
+	trace.SetTag("url", i.target)
+	trace.SetTag("target", method)
+	trace.SetTag("client_stream", desc.ClientStreams)
+	trace.SetTag("server_stream", desc.ServerStreams)
+
+	s := &tracedStream{
+		ctx:           ctx,
+		trace:         trace,
+		start:         time.Now(),
+		serviceName:   i.serviceName,
+		serverStreams: desc.ServerStreams,
+		tp: logger.ThirdParty{
+			ServiceTarget: method,
+			URL:           i.target,
+			Method:        http.MethodPost,
+		},
+	}
+
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		s.finish(err)
+		return nil, err
+	}
+	s.ClientStream = cs
+
+	// stream is finished by its terminal RecvMsg or SendMsg result, stream context is also cancelled when
+	// the stream ends normally so context error is reported only when the caller cancelled the call
+	go func() {
+		<-cs.Context().Done()
+		if err := s.ctx.Err(); err != nil {
+			s.finish(err)
+		}
+	}()
+
+	return s, nil
+}
+
+// streamMetadata send request id and trace context to server
+func (i *interceptor) streamMetadata(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoingMetadata(ctx), desc, cc, method, opts...)
+}
+
+type errorkitStream struct {
+	grpc.ClientStream
+}
+
+func (s *errorkitStream) SendMsg(m interface{}) error {
+	if err := s.ClientStream.SendMsg(m); err != nil && err != io.EOF {
+		return errorkit.FromStatus(err)
+	}
+
+	return nil
+}
+
+func (s *errorkitStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil && err != io.EOF {
+		return errorkit.FromStatus(err)
+	}
+
+	return err
+}
+
+type tracedStream struct {
+	grpc.ClientStream
+
+	ctx         context.Context
+	trace       tracer.Tracer
+	start       time.Time
+	serviceName string
+	tp          logger.ThirdParty
+	// serverStreams stream without server streaming ends at the first response
+	serverStreams bool
+
+	mu       sync.Mutex
+	sent     int
+	received int
+	once     sync.Once
+}
+
+func (s *tracedStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	switch {
+	case err == nil:
+		s.mu.Lock()
+		s.sent++
+		s.mu.Unlock()
+	case !errors.Is(err, io.EOF):
+		// io.EOF means the stream is ended by server, the status is returned by RecvMsg
+		s.finish(err)
+	}
+
+	return err
+}
+
+func (s *tracedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		s.mu.Lock()
+		s.received++
+		s.mu.Unlock()
+		if !s.serverStreams {
+			s.finish(nil)
+		}
+	case errors.Is(err, io.EOF):
+		s.finish(nil)
+	default:
+		s.finish(err)
+	}
+
+	return err
+}
+
+// finish log and finish span of stream once
+func (s *tracedStream) finish(err error) {
+	s.once.Do(func() {
+		sc := errorkit.HTTPFromCode(status.Code(err))
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			sc = errorkit.HTTPFromCode(status.FromContextError(err).Code())
+		}
+		if err != nil {
+			s.trace.SetError(err)
+			s.tp.Response = err.Error()
+		}
+
+		s.mu.Lock()
+		s.trace.SetTag("message_sent", s.sent)
+		s.trace.SetTag("message_received", s.received)
+		s.mu.Unlock()
+
+		end := time.Since(s.start)
+		s.tp.StatusCode = sc
+		s.tp.ExecTime = end.Seconds()
+		s.tp.Store(s.ctx)
+
+		s.trace.SetTag("status_code", sc)
+		s.trace.Finish()
+
+		monitoring.PrometheusRecord(sc, s.tp.Method, s.tp.ServiceTarget, s.serviceName, end)
+	})
+}
+
+// outgoingMetadata append request id of logger and trace context of span into outgoing metadata
+func outgoingMetadata(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+
+	if len(md.Get(HeaderRequestId)) < 1 {
+		md.Set(HeaderRequestId, logger.GetRequestId(ctx))
+	}
+
+	propagator := propagation.NewCompositeTextMapPropagator(otel.GetTextMapPropagator(), propagation.Baggage{})
+	propagator.Inject(ctx, metadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// metadataCarrier adapt grpc metadata to propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if v := metadata.MD(mc).Get(key); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
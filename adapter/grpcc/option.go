@@ -0,0 +1,175 @@
+package grpcc
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vizucode/gokit/utils/env"
+	"github.com/vizucode/gokit/utils/protolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+)
+
+// OptionFunc setter client options
+type OptionFunc func(*option)
+
+type option struct {
+	serviceName string
+	// secureTLS dial with tls, insecure when nil
+	secureTLS *tls.Config
+	keepalive keepalive.ClientParameters
+	// timeout default deadline of call when context has no deadline
+	timeout time.Duration
+	// serviceConfig json service config, e.g. retry policy
+	serviceConfig string
+	// errorkit convert received status into errorkit error
+	errorkit bool
+	// redactor render logged request and response, redact fields with debug_redact option or listed path
+	redactor *protolog.Redactor
+
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+	dialOptions        []grpc.DialOption
+}
+
+func defaultOption() option {
+	return option{
+		serviceName: filepath.Base(os.Args[0]),
+		keepalive: keepalive.ClientParameters{
+			Time:                env.GetDuration("GRPC_CLIENT_KEEPALIVE_TIME", 30*time.Second),
+			Timeout:             env.GetDuration("GRPC_CLIENT_KEEPALIVE_TIMEOUT", 10*time.Second),
+			PermitWithoutStream: true,
+		},
+		timeout:  env.GetDuration("GRPC_CLIENT_TIMEOUT", 10*time.Second),
+		errorkit: true,
+		redactor: protolog.New(strings.Split(env.GetString("GRPC_CLIENT_LOG_REDACT_PATHS", ""), ",")...),
+	}
+}
+
+// SetServiceName set service name of metrics
+func SetServiceName(serviceName string) OptionFunc {
+	return func(o *option) {
+		o.serviceName = serviceName
+	}
+}
+
+// SetSecureTLS dial with tls, use certificate with client certificate for mutual tls
+func SetSecureTLS(secureTLS *tls.Config) OptionFunc {
+	return func(o *option) {
+		o.secureTLS = secureTLS
+	}
+}
+
+// SetKeepalive set keepalive parameters of connection
+func SetKeepalive(params keepalive.ClientParameters) OptionFunc {
+	return func(o *option) {
+		o.keepalive = params
+	}
+}
+
+// SetTimeout set default deadline of call when context has no deadline, zero disable it
+func SetTimeout(timeout time.Duration) OptionFunc {
+	return func(o *option) {
+		o.timeout = timeout
+	}
+}
+
+// SetRetryPolicy retry call with exponential backoff on the given status codes, applied to all methods
+func SetRetryPolicy(maxAttempts int, initialBackoff, maxBackoff time.Duration, retryableCodes ...codes.Code) OptionFunc {
+	return func(o *option) {
+		if len(retryableCodes) < 1 {
+			retryableCodes = []codes.Code{codes.Unavailable}
+		}
+
+		names := make([]string, 0, len(retryableCodes))
+		for _, c := range retryableCodes {
+			names = append(names, toServiceConfigCode(c))
+		}
+
+		config, _ := json.Marshal(map[string]interface{}{
+			"methodConfig": []interface{}{map[string]interface{}{
+				"name": []interface{}{map[string]interface{}{}},
+				"retryPolicy": map[string]interface{}{
+					"maxAttempts":          maxAttempts,
+					"initialBackoff":       toServiceConfigDuration(initialBackoff),
+					"maxBackoff":           toServiceConfigDuration(maxBackoff),
+					"backoffMultiplier":    2,
+					"retryableStatusCodes": names,
+				},
+			}},
+		})
+		o.serviceConfig = string(config)
+	}
+}
+
+// SetServiceConfig set json service config of connection, replace retry policy
+func SetServiceConfig(serviceConfig string) OptionFunc {
+	return func(o *option) {
+		o.serviceConfig = serviceConfig
+	}
+}
+
+// SetErrorkit convert status received from server into errorkit error, enabled by default
+func SetErrorkit(enable bool) OptionFunc {
+	return func(o *option) {
+		o.errorkit = enable
+	}
+}
+
+// SetLogRedactPaths redact request and response fields on logging, path is proto field names joined by dot,
+// e.g. user.password, or field name matched at any depth. Fields with debug_redact option are always redacted
+func SetLogRedactPaths(paths ...string) OptionFunc {
+	return func(o *option) {
+		o.redactor = protolog.New(paths...)
+	}
+}
+
+// SetUnaryInterceptors add unary interceptors run after logging interceptor
+func SetUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) OptionFunc {
+	return func(o *option) {
+		o.unaryInterceptors = append(o.unaryInterceptors, interceptors...)
+	}
+}
+
+// SetStreamInterceptors add stream interceptors run after logging interceptor
+func SetStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) OptionFunc {
+	return func(o *option) {
+		o.streamInterceptors = append(o.streamInterceptors, interceptors...)
+	}
+}
+
+// SetDialOptions add grpc dial options, applied after options set by the factory
+func SetDialOptions(opts ...grpc.DialOption) OptionFunc {
+	return func(o *option) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+func toServiceConfigDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// toServiceConfigCode upper snake case name of code, e.g. DEADLINE_EXCEEDED
+func toServiceConfigCode(c codes.Code) string {
+	name := c.String()
+
+	var out []byte
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if ch >= 'A' && ch <= 'Z' && i > 0 {
+			out = append(out, '_')
+		}
+		if ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		out = append(out, ch)
+	}
+
+	return string(out)
+}
@@ -0,0 +1,317 @@
+package rabbitmqc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/tracer"
+	"github.com/vizucode/gokit/types"
+	"github.com/vizucode/gokit/utils/convert"
+	"github.com/vizucode/gokit/utils/monitoring"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// HeaderRequestId message header of request id, read by consumer logging
+const HeaderRequestId = "x-request-id"
+
+var (
+	// ErrUnroutable mandatory message is returned by broker, no queue is bound to the routing key
+	ErrUnroutable = errors.New("rabbitmq: message is unroutable")
+	// ErrNack broker refused the message
+	ErrNack = errors.New("rabbitmq: message is nacked by broker")
+	// ErrConfirmTimeout broker confirmation is not received within confirm timeout
+	ErrConfirmTimeout = errors.New("rabbitmq: confirmation timeout")
+	// ErrDisconnected connection is lost and not recovered yet
+	ErrDisconnected = errors.New("rabbitmq: disconnected")
+)
+
+// channel publishing side of amqp channel
+type channel interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// pending publish waiting its confirmation, returned message is recorded before the confirmation arrive
+type pending struct {
+	messageId string
+	ret       *amqp.Return
+	done      chan error
+}
+
+// publisher publish message on confirm mode channel. Concurrent publishes wait their own delivery tag,
+// returned message is matched by message id
+type publisher struct {
+	opt option
+
+	mu      sync.Mutex
+	ch      channel
+	seq     uint64
+	waiting map[uint64]*pending
+	byId    map[string]*pending
+}
+
+func newPublisher(opt option) *publisher {
+	return &publisher{opt: opt}
+}
+
+// reset publish on channel of new connection, delivery tag of confirmation start again from one
+func (p *publisher) reset(ch *amqp.Channel) error {
+	if err := ch.Confirm(false); err != nil {
+		return fmt.Errorf("rabbitmq: confirm mode: %w", err)
+	}
+
+	// unbuffered so the next notification is not sent by amqp before the previous one is received,
+	// returned message of mandatory publish always precede its confirmation
+	p.attach(ch, ch.NotifyPublish(make(chan amqp.Confirmation)), ch.NotifyReturn(make(chan amqp.Return)))
+
+	return nil
+}
+
+// attach channel and dispatch its notifications to pending publishes
+func (p *publisher) attach(ch channel, confirms <-chan amqp.Confirmation, returns <-chan amqp.Return) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ch, p.seq = ch, 0
+	p.waiting, p.byId = make(map[uint64]*pending), make(map[string]*pending)
+	go p.dispatch(confirms, returns, p.waiting, p.byId)
+}
+
+// dispatch notification of channel in received order, notification of timed out publish is ignored.
+// Publish still waiting when the channel is closed fail
+func (p *publisher) dispatch(confirms <-chan amqp.Confirmation, returns <-chan amqp.Return, waiting map[uint64]*pending, byId map[string]*pending) {
+	for confirms != nil || returns != nil {
+		select {
+		case c, ok := <-confirms:
+			if !ok {
+				confirms = nil
+				continue
+			}
+
+			p.mu.Lock()
+			if w, ok := waiting[c.DeliveryTag]; ok {
+				delete(waiting, c.DeliveryTag)
+				delete(byId, w.messageId)
+
+				switch {
+				case w.ret != nil:
+					w.done <- fmt.Errorf("%w: %d %s", ErrUnroutable, w.ret.ReplyCode, w.ret.ReplyText)
+				case !c.Ack:
+					w.done <- ErrNack
+				default:
+					w.done <- nil
+				}
+			}
+			p.mu.Unlock()
+		case r, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+
+			p.mu.Lock()
+			if w, ok := byId[r.MessageId]; ok {
+				w.ret = &r
+			}
+			p.mu.Unlock()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for tag, w := range waiting {
+		delete(waiting, tag)
+		delete(byId, w.messageId)
+		close(w.done)
+	}
+}
+
+// PublishMessage publish message and wait broker confirmation. Routing key is Key, or Topic, or Queue
+// when published to default exchange. Trace context and request id are injected into headers
+func (p *publisher) PublishMessage(ctx context.Context, req types.PublisherArgument) (err error) {
+	start := time.Now()
+
+	key := req.Key
+	if key == "" {
+		key = req.Topic
+	}
+	if key == "" {
+		key = req.Queue
+	}
+
+	trace, ctx := tracer.StartTraceWithContext(ctx, fmt.Sprintf("RabbitMQPublisher:%s", key))
+
+	msg := amqp.Publishing{
+		Headers:       amqp.Table{},
+		ContentType:   req.ContentType,
+		CorrelationId: req.CorrelationId,
+		MessageId:     uuid.NewString(),
+		Timestamp:     start,
+		AppId:         p.opt.serviceName,
+		Priority:      priority(req.PriorityMessage),
+		Body:          req.Message,
+	}
+	if p.opt.persistent {
+		msg.DeliveryMode = amqp.Persistent
+	}
+	if req.TTL > 0 {
+		msg.Expiration = strconv.FormatInt(req.TTL.Milliseconds(), 10)
+	}
+
+	for k, v := range req.Headers {
+		msg.Headers[k] = v
+	}
+	otel.GetTextMapPropagator().Inject(ctx, tableCarrier(msg.Headers))
+	if _, ok := msg.Headers[HeaderRequestId]; !ok && ctx.Value(logger.LogKey) != nil {
+		msg.Headers[HeaderRequestId] = logger.GetRequestId(ctx)
+	}
+
+	tp := logger.ThirdParty{
+		ServiceTarget: fmt.Sprintf("RabbitMQ:%s", req.Exchange),
+		URL:           fmt.Sprintf("%s/%s", req.Exchange, key),
+		RequestHeader: convert.ToString(msg.Headers),
+		RequestBody:   string(req.Message),
+		Method:        "PUBLISH",
+	}
+
+	defer func() {
+		sc := http.StatusOK
+		switch {
+		case errors.Is(err, ErrUnroutable):
+			sc = http.StatusNotFound
+		case errors.Is(err, ErrConfirmTimeout), errors.Is(err, context.DeadlineExceeded):
+			sc = http.StatusGatewayTimeout
+		case errors.Is(err, ErrDisconnected), errors.Is(err, amqp.ErrClosed):
+			sc = http.StatusServiceUnavailable
+		case err != nil:
+			sc = http.StatusInternalServerError
+		}
+
+		if err != nil {
+			trace.SetError(err)
+			tp.Response = err.Error()
+		} else {
+			tp.Response = "confirmed"
+		}
+
+		end := time.Since(start)
+		tp.StatusCode = sc
+		tp.ExecTime = end.Seconds()
+		tp.Store(ctx)
+
+		trace.SetTag("status_code", sc)
+		trace.Finish()
+
+		monitoring.PrometheusRecord(sc, tp.Method, tp.URL, p.opt.serviceName, end)
+	}()
+
+	trace.SetTag("exchange", req.Exchange)
+	trace.SetTag("routing_key", key)
+	trace.SetTag("message_id", msg.MessageId)
+	trace.SetTag("correlation_id", msg.CorrelationId)
+
+	return p.publish(ctx, req.Exchange, key, msg)
+}
+
+// publish send message and wait its confirmation, the lock is held only while publishing
+// so concurrent publishes wait their confirmation together
+func (p *publisher) publish(ctx context.Context, exchange, key string, msg amqp.Publishing) error {
+	p.mu.Lock()
+	if p.ch == nil {
+		p.mu.Unlock()
+		return ErrDisconnected
+	}
+
+	if err := p.ch.Publish(exchange, key, p.opt.mandatory, false, msg); err != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("rabbitmq: publish: %w", err)
+	}
+
+	// delivery tag is counted by channel from one, the lock keep it in step with publish
+	p.seq++
+	tag, w := p.seq, &pending{messageId: msg.MessageId, done: make(chan error, 1)}
+	waiting, byId := p.waiting, p.byId
+	waiting[tag], byId[w.messageId] = w, w
+	p.mu.Unlock()
+
+	timeout := time.NewTimer(p.opt.confirmTimeout)
+	defer timeout.Stop()
+
+	var err error
+	select {
+	case result, ok := <-w.done:
+		if !ok {
+			return amqp.ErrClosed
+		}
+		return result
+	case <-timeout.C:
+		err = ErrConfirmTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	p.mu.Lock()
+	delete(waiting, tag)
+	delete(byId, w.messageId)
+	p.mu.Unlock()
+
+	return err
+}
+
+// close channel of publisher, closed without lock since dispatch need it to receive pending notification
+func (p *publisher) close() error {
+	p.mu.Lock()
+	ch := p.ch
+	p.mu.Unlock()
+
+	if ch == nil {
+		return nil
+	}
+
+	return ch.Close()
+}
+
+// priority clamp priority into amqp priority range
+func priority(v int) uint8 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 255:
+		return 255
+	default:
+		return uint8(v)
+	}
+}
+
+// tableCarrier adapt amqp headers to propagation.TextMapCarrier
+type tableCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = tableCarrier{}
+
+func (t tableCarrier) Get(key string) string {
+	v, _ := t[key].(string)
+	return v
+}
+
+func (t tableCarrier) Set(key, value string) {
+	t[key] = value
+}
+
+func (t tableCarrier) Keys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
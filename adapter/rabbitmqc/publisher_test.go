@@ -0,0 +1,188 @@
+package rabbitmqc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/vizucode/gokit/types"
+)
+
+// fakeChannel answer every publish like broker, returned message is sent before confirmation
+type fakeChannel struct {
+	confirms chan amqp.Confirmation
+	returns  chan amqp.Return
+	// answer notifications of published message, nil is not answered
+	answer func(tag uint64, msg amqp.Publishing) (*amqp.Return, *amqp.Confirmation)
+	// delay round trip of broker
+	delay time.Duration
+
+	tag uint64
+}
+
+func newFakePublisher(answer func(tag uint64, msg amqp.Publishing) (*amqp.Return, *amqp.Confirmation)) (*publisher, *fakeChannel) {
+	ch := &fakeChannel{
+		confirms: make(chan amqp.Confirmation),
+		returns:  make(chan amqp.Return),
+		answer:   answer,
+	}
+
+	p := newPublisher(option{confirmTimeout: 100 * time.Millisecond, mandatory: true})
+	p.attach(ch, ch.confirms, ch.returns)
+
+	return p, ch
+}
+
+func (f *fakeChannel) Publish(_, _ string, _, _ bool, msg amqp.Publishing) error {
+	f.tag++
+	ret, confirm := f.answer(f.tag, msg)
+
+	go func() {
+		time.Sleep(f.delay)
+		if ret != nil {
+			f.returns <- *ret
+		}
+		if confirm != nil {
+			f.confirms <- *confirm
+		}
+	}()
+
+	return nil
+}
+
+func (f *fakeChannel) Close() error {
+	close(f.confirms)
+	close(f.returns)
+	return nil
+}
+
+func TestPublishResult(t *testing.T) {
+	tests := map[string]struct {
+		answer func(tag uint64, msg amqp.Publishing) (*amqp.Return, *amqp.Confirmation)
+		want   error
+		repeat int
+	}{
+		"confirmed": {
+			answer: func(tag uint64, _ amqp.Publishing) (*amqp.Return, *amqp.Confirmation) {
+				return nil, &amqp.Confirmation{DeliveryTag: tag, Ack: true}
+			},
+		},
+		"unroutable": {
+			answer: func(tag uint64, msg amqp.Publishing) (*amqp.Return, *amqp.Confirmation) {
+				return &amqp.Return{MessageId: msg.MessageId, ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE"}, &amqp.Confirmation{DeliveryTag: tag, Ack: true}
+			},
+			want: ErrUnroutable,
+		},
+		"nacked": {
+			answer: func(tag uint64, _ amqp.Publishing) (*amqp.Return, *amqp.Confirmation) {
+				return nil, &amqp.Confirmation{DeliveryTag: tag}
+			},
+			want: ErrNack,
+		},
+		"timeout": {
+			answer: func(uint64, amqp.Publishing) (*amqp.Return, *amqp.Confirmation) {
+				return nil, nil
+			},
+			want:   ErrConfirmTimeout,
+			repeat: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, _ := newFakePublisher(tt.answer)
+
+			// repeated so the order of returned message and confirmation is exercised
+			if tt.repeat == 0 {
+				tt.repeat = 50
+			}
+			for i := 0; i < tt.repeat; i++ {
+				err := p.PublishMessage(context.Background(), types.PublisherArgument{Queue: "order", Message: []byte("{}")})
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("publish %d: got %v, want %v", i, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishConcurrent(t *testing.T) {
+	// broker confirm every message after a round trip, concurrent publishes must not wait each other
+	p, ch := newFakePublisher(func(tag uint64, _ amqp.Publishing) (*amqp.Return, *amqp.Confirmation) {
+		return nil, &amqp.Confirmation{DeliveryTag: tag, Ack: true}
+	})
+	ch.delay = 100 * time.Millisecond
+	p.opt.confirmTimeout = 5 * time.Second
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- p.PublishMessage(context.Background(), types.PublisherArgument{Queue: "order"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("20 publishes took %s, publishes are serialized", elapsed)
+	}
+}
+
+func TestPublishSkipStale(t *testing.T) {
+	// the first publish is answered after it is timed out, the late answer must not decide the second publish
+	var first amqp.Publishing
+	p, ch := newFakePublisher(func(tag uint64, msg amqp.Publishing) (*amqp.Return, *amqp.Confirmation) {
+		if tag == 1 {
+			first = msg
+			return nil, nil
+		}
+		return nil, &amqp.Confirmation{DeliveryTag: tag, Ack: true}
+	})
+
+	if err := p.PublishMessage(context.Background(), types.PublisherArgument{Queue: "order"}); !errors.Is(err, ErrConfirmTimeout) {
+		t.Fatalf("got %v, want timeout", err)
+	}
+
+	ch.returns <- amqp.Return{MessageId: first.MessageId, ReplyCode: amqp.NoRoute}
+	ch.confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+
+	if err := p.PublishMessage(context.Background(), types.PublisherArgument{Queue: "order"}); err != nil {
+		t.Fatalf("got %v, want confirmed", err)
+	}
+}
+
+func TestPublishClosed(t *testing.T) {
+	p, ch := newFakePublisher(func(uint64, amqp.Publishing) (*amqp.Return, *amqp.Confirmation) {
+		return nil, nil
+	})
+	p.opt.confirmTimeout = time.Second
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = ch.Close()
+	}()
+
+	if err := p.PublishMessage(context.Background(), types.PublisherArgument{Queue: "order"}); !errors.Is(err, amqp.ErrClosed) {
+		t.Fatalf("got %v, want closed", err)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	for in, want := range map[int]uint8{-1: 0, 5: 5, 300: 255} {
+		if got := priority(in); got != want {
+			t.Errorf("priority(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package rabbitmqc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hellofresh/health-go/v4"
+	"github.com/streadway/amqp"
+	"github.com/vizucode/gokit/abstract"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/types"
+	"github.com/vizucode/gokit/utils/backoff"
+	"github.com/vizucode/gokit/utils/monitoring"
+)
+
+// connection amqp connection of broker, implemented by *amqp.Connection
+type connection interface {
+	Channel() (*amqp.Channel, error)
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	IsClosed() bool
+	Close() error
+}
+
+// RabbitMQ broker connection implementing abstract.Broker, the connection is supervised and reconnected
+// with backoff when lost. Consumer open their own channel with Channel, publisher channel is recovered
+type RabbitMQ struct {
+	opt option
+	// host address of broker without credential, used by metrics
+	host string
+
+	// dial open connection to broker, setup prepare it before use
+	dial  func() (connection, error)
+	setup func(conn connection) error
+
+	mu   sync.RWMutex
+	conn connection
+	// ready closed while connected, replaced when connection is lost
+	ready chan struct{}
+	// done closed by Disconnect, stop reconnecting
+	done      chan struct{}
+	closeOnce sync.Once
+
+	publisher *publisher
+}
+
+// NewRabbitMQ connect to broker, panic when failed
+func NewRabbitMQ(opts ...OptionFunc) *RabbitMQ {
+	r := &RabbitMQ{
+		opt:   defaultOption(),
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&r.opt)
+	}
+
+	r.host = r.opt.uri
+	if uri, err := amqp.ParseURI(r.opt.uri); err == nil {
+		r.host = fmt.Sprintf("%s:%d/%s", uri.Host, uri.Port, uri.Vhost)
+	}
+
+	r.publisher = newPublisher(r.opt)
+	r.dial, r.setup = r.dialBroker, r.setupConnection
+
+	closed, err := r.connect()
+	if err != nil {
+		panic(err)
+	}
+	go r.supervise(closed)
+
+	return r
+}
+
+// connect dial and setup connection, closed is notified when the connection is lost
+func (r *RabbitMQ) connect() (chan *amqp.Error, error) {
+	conn, err := r.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.setup(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		_ = conn.Close()
+		return nil, amqp.ErrClosed
+	default:
+	}
+
+	r.conn = conn
+	close(r.ready)
+	monitoring.BrokerConnection("rabbitmq", r.host, true)
+
+	return closed, nil
+}
+
+// dialBroker dial broker uri, with tls when configured
+func (r *RabbitMQ) dialBroker() (connection, error) {
+	var (
+		conn *amqp.Connection
+		err  error
+	)
+	if r.opt.secureTLS != nil {
+		conn, err = amqp.DialTLS(r.opt.uri, r.opt.secureTLS)
+	} else {
+		conn, err = amqp.Dial(r.opt.uri)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rabbitmq: dial: %w", err)
+	}
+
+	return conn, nil
+}
+
+// setupConnection declare topology and open publisher channel
+func (r *RabbitMQ) setupConnection(conn connection) error {
+	if err := r.declare(conn); err != nil {
+		return err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("rabbitmq: publisher channel: %w", err)
+	}
+
+	return r.publisher.reset(ch)
+}
+
+// declare topology of publisher on temporary channel
+func (r *RabbitMQ) declare(conn connection) error {
+	if len(r.opt.topology) == 0 {
+		return nil
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("rabbitmq: topology channel: %w", err)
+	}
+	defer ch.Close()
+
+	for _, declare := range r.opt.topology {
+		if err = declare(ch); err != nil {
+			return fmt.Errorf("rabbitmq: declare topology: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// supervise wait connection to be closed and reconnect until Disconnect is called
+func (r *RabbitMQ) supervise(closed chan *amqp.Error) {
+	for {
+		var err *amqp.Error
+		select {
+		case <-r.done:
+			return
+		case err = <-closed:
+		}
+
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+
+		r.mu.Lock()
+		r.ready = make(chan struct{})
+		r.mu.Unlock()
+
+		monitoring.BrokerConnection("rabbitmq", r.host, false)
+		logger.Red(fmt.Sprintf("[RABBITMQ] connection lost: %v", err))
+
+		if closed = r.reconnect(); closed == nil {
+			return
+		}
+	}
+}
+
+// reconnect dial with exponential backoff, nil when disconnected while reconnecting
+func (r *RabbitMQ) reconnect() chan *amqp.Error {
+	for attempt := 0; ; attempt++ {
+		delay := backoff.Jitter(backoff.Exponential(attempt, r.opt.reconnectMinBackoff, r.opt.reconnectMaxBackoff))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-r.done:
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		closed, err := r.connect()
+		if err == nil {
+			logger.Green(fmt.Sprintf("[RABBITMQ] reconnected after %d attempt", attempt+1))
+			return closed
+		}
+
+		if errors.Is(err, amqp.ErrClosed) {
+			return nil
+		}
+		logger.Red(fmt.Sprintf("[RABBITMQ] reconnect attempt %d failed: %s", attempt+1, err))
+	}
+}
+
+// Channel open channel of current connection, wait until the connection is recovered while disconnected
+func (r *RabbitMQ) Channel(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		r.mu.RLock()
+		conn, ready := r.conn, r.ready
+		r.mu.RUnlock()
+
+		select {
+		case <-r.done:
+			return nil, amqp.ErrClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ready:
+		}
+
+		ch, err := conn.Channel()
+		if err == nil {
+			return ch, nil
+		}
+		if !conn.IsClosed() {
+			return nil, fmt.Errorf("rabbitmq: channel: %w", err)
+		}
+
+		// connection is lost but not noticed by supervisor yet
+		timer := time.NewTimer(r.opt.reconnectMinBackoff)
+		select {
+		case <-r.done:
+			timer.Stop()
+			return nil, amqp.ErrClosed
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Connected connection is open
+func (r *RabbitMQ) Connected() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	select {
+	case <-r.done:
+		return false
+	case <-r.ready:
+		return !r.conn.IsClosed()
+	default:
+		return false
+	}
+}
+
+// HealthCheck dependency check of connection, it is not registered automatically, register it by hand
+// with server.SetHealthChecks or return it from HealthChecks of the service
+func (r *RabbitMQ) HealthCheck() health.Config {
+	return health.Config{
+		Name:    "rabbitmq",
+		Timeout: time.Second,
+		Check: func(context.Context) error {
+			if !r.Connected() {
+				return ErrDisconnected
+			}
+			return nil
+		},
+	}
+}
+
+// GetPublisher publisher with broker confirmation
+func (r *RabbitMQ) GetPublisher() abstract.Publisher {
+	return r.publisher
+}
+
+// GetName broker name
+func (r *RabbitMQ) GetName() types.Broker {
+	return types.RabbitMQ
+}
+
+// GetConfiguration the broker itself, consumer open and recover channels with Channel
+func (r *RabbitMQ) GetConfiguration() interface{} {
+	return r
+}
+
+// Disconnect stop reconnecting, close publisher channel and connection
+func (r *RabbitMQ) Disconnect(_ context.Context) (err error) {
+	r.closeOnce.Do(func() {
+		defer logger.RedBold("Closing RabbitMQ Connection")
+
+		connected := r.Connected()
+
+		r.mu.Lock()
+		close(r.done)
+		conn := r.conn
+		r.mu.Unlock()
+
+		if connected {
+			monitoring.BrokerConnection("rabbitmq", r.host, false)
+		}
+
+		if conn.IsClosed() {
+			return
+		}
+		err = errors.Join(r.publisher.close(), conn.Close())
+	})
+
+	return err
+}
+
+// Close close connection, used as closer of service
+func (r *RabbitMQ) Close() error {
+	return r.Disconnect(context.Background())
+}
@@ -0,0 +1,270 @@
+package rabbitmqc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// fakeConnection connection which is lost by drop
+type fakeConnection struct {
+	mu     sync.Mutex
+	closed bool
+	notify chan *amqp.Error
+}
+
+func (f *fakeConnection) Channel() (*amqp.Channel, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, amqp.ErrClosed
+	}
+
+	return new(amqp.Channel), nil
+}
+
+func (f *fakeConnection) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.notify = receiver
+	return receiver
+}
+
+func (f *fakeConnection) IsClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.closed
+}
+
+// Close close gracefully, notification is closed without error like amqp does
+func (f *fakeConnection) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return amqp.ErrClosed
+	}
+
+	f.closed = true
+	if f.notify != nil {
+		close(f.notify)
+	}
+
+	return nil
+}
+
+// drop lose connection as broker went away
+func (f *fakeConnection) drop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	f.notify <- &amqp.Error{Code: amqp.ConnectionForced, Reason: "broker is gone"}
+	close(f.notify)
+}
+
+// fakeDialer hand out connections sent to conns, dial fail when error is sent instead
+type fakeDialer struct {
+	conns  chan *fakeConnection
+	errs   chan error
+	dialed chan struct{}
+}
+
+func newFakeDialer() *fakeDialer {
+	return &fakeDialer{conns: make(chan *fakeConnection, 1), errs: make(chan error, 1), dialed: make(chan struct{}, 10)}
+}
+
+func (d *fakeDialer) dial() (connection, error) {
+	d.dialed <- struct{}{}
+
+	select {
+	case conn := <-d.conns:
+		return conn, nil
+	case err := <-d.errs:
+		return nil, err
+	}
+}
+
+// newFakeRabbitMQ connect with first connection of dialer, exited is closed when supervisor stop
+func newFakeRabbitMQ(t *testing.T, d *fakeDialer, backoff time.Duration) (r *RabbitMQ, exited chan struct{}) {
+	t.Helper()
+
+	r = &RabbitMQ{
+		opt:   option{reconnectMinBackoff: backoff, reconnectMaxBackoff: backoff},
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	r.publisher = newPublisher(r.opt)
+	r.dial, r.setup = d.dial, func(connection) error { return nil }
+
+	closed, err := r.connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-d.dialed
+
+	exited = make(chan struct{})
+	go func() {
+		defer close(exited)
+		r.supervise(closed)
+	}()
+	t.Cleanup(func() { _ = r.Close() })
+
+	return r, exited
+}
+
+// waitConnected wait until connection is recovered
+func waitConnected(t *testing.T, r *RabbitMQ) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); !r.Connected(); {
+		if time.Now().After(deadline) {
+			t.Fatal("connection is not recovered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	d := newFakeDialer()
+	first := &fakeConnection{}
+	d.conns <- first
+	r, _ := newFakeRabbitMQ(t, d, time.Millisecond)
+
+	second := &fakeConnection{}
+	d.errs <- errors.New("connection refused")
+	first.drop()
+
+	// failed attempt is retried
+	<-d.dialed
+	<-d.dialed
+	d.conns <- second
+	waitConnected(t, r)
+
+	r.mu.RLock()
+	conn := r.conn
+	r.mu.RUnlock()
+	if conn != second {
+		t.Fatal("broker does not use reconnected connection")
+	}
+
+	if _, err := r.Channel(context.Background()); err != nil {
+		t.Fatalf("channel of reconnected connection: %s", err)
+	}
+}
+
+func TestChannelWaitReady(t *testing.T) {
+	d := newFakeDialer()
+	first := &fakeConnection{}
+	d.conns <- first
+	r, _ := newFakeRabbitMQ(t, d, time.Millisecond)
+
+	first.drop()
+	<-d.dialed
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.Channel(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("channel while disconnected got %v, want deadline exceeded", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := r.Channel(context.Background())
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		t.Fatalf("channel returned %v before connection is recovered", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	d.conns <- &fakeConnection{}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("channel after recovered: %s", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("channel is not returned after connection is recovered")
+	}
+}
+
+func TestDisconnectDuringBackoff(t *testing.T) {
+	d := newFakeDialer()
+	first := &fakeConnection{}
+	d.conns <- first
+	r, exited := newFakeRabbitMQ(t, d, time.Hour)
+
+	first.drop()
+	for r.Connected() {
+		time.Sleep(time.Millisecond)
+	}
+
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := r.Channel(context.Background())
+		waiting <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := r.Disconnect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor is still reconnecting after disconnect")
+	}
+
+	if err := <-waiting; !errors.Is(err, amqp.ErrClosed) {
+		t.Fatalf("waiting channel got %v, want closed", err)
+	}
+
+	if r.Connected() {
+		t.Fatal("connected after disconnect")
+	}
+
+	select {
+	case <-d.dialed:
+		t.Fatal("dialed after disconnect")
+	default:
+	}
+}
+
+func TestDisconnectDuringDial(t *testing.T) {
+	d := newFakeDialer()
+	first := &fakeConnection{}
+	d.conns <- first
+	r, exited := newFakeRabbitMQ(t, d, time.Millisecond)
+
+	first.drop()
+	<-d.dialed
+
+	if err := r.Disconnect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// connection dialed while disconnecting is closed and not used
+	late := &fakeConnection{}
+	d.conns <- late
+
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor is still reconnecting after disconnect")
+	}
+
+	if !late.IsClosed() {
+		t.Fatal("connection dialed after disconnect is left open")
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/vizucode/gokit/adapter/dbc"
+	"github.com/vizucode/gokit/adapter/grpcc"
 	"github.com/vizucode/gokit/config"
 	"github.com/vizucode/gokit/factory/server"
 	gokitrpc "github.com/vizucode/gokit/factory/server/grpc"
@@ -17,10 +18,8 @@ import (
 	"github.com/vizucode/gokit/logger"
 	pb "github.com/vizucode/gokit/protoc"
 	"github.com/vizucode/gokit/utils/constant"
-	"github.com/vizucode/gokit/utils/errorkit"
 	"github.com/vizucode/gokit/utils/request"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"gorm.io/gorm"
 )
 
@@ -31,14 +30,16 @@ type restRoute struct {
 	SQLDB *sql.DB
 	REDIS dbc.CacheClient
 	API   request.Client
+	RPC   *grpcc.Factory
 }
 
-func NewHandler(gormDB *gorm.DB, sqlDB *sql.DB, redis dbc.CacheClient, apiClient request.Client) *restRoute {
+func NewHandler(gormDB *gorm.DB, sqlDB *sql.DB, redis dbc.CacheClient, apiClient request.Client, rpcClient *grpcc.Factory) *restRoute {
 	return &restRoute{
 		GORM:  gormDB,
 		SQLDB: sqlDB,
 		REDIS: redis,
 		API:   apiClient,
+		RPC:   rpcClient,
 	}
 }
 
@@ -134,17 +135,7 @@ func (r *restRoute) Router(router fiber.Router) {
 	v1.Get("/with-request-grpc", func(c *fiber.Ctx) error {
 		ctx := c.UserContext()
 
-		host := fmt.Sprintf("%s:%s", "localhost", "3005")
-		intercept := logger.NewInterceptor(host)
-
-		opts := []grpc.DialOption{
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-			grpc.WithUnaryInterceptor(intercept.ChainUnaryClient(
-				intercept.UnaryClientTracerInterceptor,
-			)),
-		}
-
-		conn, err := grpc.NewClient(host, opts...)
+		conn, err := r.RPC.Conn(fmt.Sprintf("%s:%s", "localhost", "3005"))
 		if err != nil {
 			logger.Log.Error(ctx, err)
 			return err
@@ -155,8 +146,9 @@ func (r *restRoute) Router(router fiber.Router) {
 			Name: "Example Name",
 		})
 		if err != nil {
+			// error is converted into errorkit error by the client factory
 			logger.Log.Error(ctx, err)
-			return errorkit.NewErrorStd(http.StatusInternalServerError, errorkit.InternalServer, err.Error())
+			return err
 		}
 
 		return c.Status(200).JSON(map[string]interface{}{
@@ -220,19 +212,26 @@ func main() {
 		dbc.SetRedisMaxPoolConnection(5),
 	)
 
+	rpcClient := grpcc.New(
+		grpcc.SetServiceName(serviceName),
+		grpcc.SetTimeout(5*time.Second),
+		grpcc.SetRetryPolicy(3, 100*time.Millisecond, time.Second),
+	)
+
 	app := server.NewService(
 		server.SetServiceName(serviceName),
-		server.SetRestHandler(NewHandler(gormDB.DB, sqlDB.DB, redisRead.DB, apiClient)),
+		server.SetRestHandler(NewHandler(gormDB.DB, sqlDB.DB, redisRead.DB, apiClient, rpcClient)),
 		server.SetRestHandlerOptions(
 			rest.SetHTTPHost("localhost"),
 			rest.SetHTTPPort(3000),
 			rest.SetErrorHandler(fiber.DefaultErrorHandler),
 		),
-		server.SetGrpcHandler(NewHandler(gormDB.DB, sqlDB.DB, redisRead.DB, apiClient)),
+		server.SetGrpcHandler(NewHandler(gormDB.DB, sqlDB.DB, redisRead.DB, apiClient, rpcClient)),
 		server.SetGrpcHandlerOptions(
 			gokitrpc.SetTCPHost("localhost"),
 			gokitrpc.SetTCPPort(3001),
 		),
+		server.SetClosers(rpcClient),
 	)
 
 	appServer := server.New(app)
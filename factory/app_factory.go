@@ -1,6 +1,9 @@
 package factory
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // ApplicationFactory factory for server and/or worker abstraction
 type ApplicationFactory interface {
@@ -11,3 +14,9 @@ type ApplicationFactory interface {
 	// Shutdown stop the server or worker
 	Shutdown(ctx context.Context)
 }
+
+// DrainingApplication optional interface of ApplicationFactory reporting not ready before shutdown
+type DrainingApplication interface {
+	// Drain flip readiness to failing while still serving, return pre-stop delay waited before Shutdown
+	Drain() time.Duration
+}
@@ -5,92 +5,212 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/hellofresh/health-go/v4"
 	"github.com/vizucode/gokit/factory"
 	"github.com/vizucode/gokit/logger"
 	"github.com/vizucode/gokit/types"
-	"github.com/vizucode/gokit/utils/env"
+	"github.com/vizucode/gokit/utils/certificate"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/keepalive"
+	channelz "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/credentials"
+	hpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
+// Server grpc application reporting the address it is bound to
+type Server interface {
+	factory.ApplicationFactory
+
+	// Ready closed when the listener is bound in Serve
+	Ready() <-chan struct{}
+	// Addr address of bound listener, the actual port when served on port zero. Nil before ready
+	Addr() net.Addr
+	// LocalTarget target and dial options to reach the server from the same process
+	LocalTarget() (string, []grpc.DialOption, error)
+}
+
 type rpc struct {
 	opt          option
 	serverEngine *grpc.Server
-	listener     net.Listener
 	service      factory.ServiceFactory
+	certificate  *certificate.Reloader
+	health       *healthServer
+	// drained health report not serving, set by Drain or Shutdown
+	drained atomic.Bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	ready    chan struct{}
 }
 
-// New create a new gRPC server
-func New(svc factory.ServiceFactory, opts ...OptionFunc) factory.ApplicationFactory {
-	var (
-		keepAliveEnforce = keepalive.EnforcementPolicy{
-			MinTime:             env.GetDuration("GRPC_MIN_TIME", time.Duration(10)*time.Second),
-			PermitWithoutStream: true,
-		}
-		keepAliveServer = keepalive.ServerParameters{
-			MaxConnectionIdle:     env.GetDuration("GRPC_MAX_CONNECTION_IDLE_DURATION", time.Duration(10)*time.Second), // if a client idle for 10s, send a go away
-			MaxConnectionAgeGrace: env.GetDuration("GRPC_MAX_CONNECTION_AGE_GRACE", time.Duration(10)*time.Second),     // allows 10s for pending RPCs to complete before forcibly closing connections
-			Time:                  env.GetDuration("GRPC_TIME_PING_CLIENT", time.Duration(10)*time.Second),             // ping the client if it's idle for 10s to ensure the connection is still alive
-			Timeout:               env.GetDuration("GRPC_TIMEOUT", time.Duration(10)*time.Second),                      // wait 10s for the ping ack before assuming the connection is dead
-		}
-		intercept = newInterceptor("", svc.Name()) // init intercept
-	)
+// New create a new gRPC server, the listener is bound when the server is served
+func New(svc factory.ServiceFactory, opts ...OptionFunc) Server {
+	intercept := newInterceptor("", svc.Name()) // init intercept
 
 	// init instance
 	srv := &rpc{
 		service: svc,
 		opt:     defaultOption(),
-		serverEngine: grpc.NewServer(
-			grpc.KeepaliveEnforcementPolicy(keepAliveEnforce),
-			grpc.KeepaliveParams(keepAliveServer),
-			grpc.UnaryInterceptor(
-				intercept.chainUnaryServer(
-					intercept.unaryServerTracerInterceptor,
-				),
-			),
-		),
+		ready:   make(chan struct{}),
 	}
 
 	for _, opt := range opts {
 		opt(&srv.opt)
 	}
 
-	tcpURI := srv.opt.tcpHost + ":" + srv.opt.tcpPort
-	var err error
-	srv.listener, err = net.Listen("tcp", tcpURI)
-	if err != nil {
-		panic(err)
+	// interceptors order: before logger, logging interceptor, after logger
+	var (
+		unaryInterceptors  []grpc.UnaryServerInterceptor
+		streamInterceptors []grpc.StreamServerInterceptor
+	)
+	unaryInterceptors = append(unaryInterceptors, srv.opt.unaryBeforeLogger...)
+	unaryInterceptors = append(unaryInterceptors, intercept.unaryServerTracerInterceptor)
+	unaryInterceptors = append(unaryInterceptors, srv.opt.unaryAfterLogger...)
+	streamInterceptors = append(streamInterceptors, srv.opt.streamBeforeLogger...)
+	streamInterceptors = append(streamInterceptors, intercept.streamServerTracerInterceptor)
+	streamInterceptors = append(streamInterceptors, srv.opt.streamAfterLogger...)
+
+	serverOptions := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(srv.opt.keepAliveEnforce),
+		grpc.KeepaliveParams(srv.opt.keepAliveServer),
+		grpc.UnaryInterceptor(intercept.chainUnaryServer(unaryInterceptors...)),
+		grpc.StreamInterceptor(intercept.chainStreamServer(streamInterceptors...)),
 	}
 
+	if srv.opt.maxRecvMsgSize > 0 {
+		serverOptions = append(serverOptions, grpc.MaxRecvMsgSize(srv.opt.maxRecvMsgSize))
+	}
+	if srv.opt.maxSendMsgSize > 0 {
+		serverOptions = append(serverOptions, grpc.MaxSendMsgSize(srv.opt.maxSendMsgSize))
+	}
+	if srv.opt.maxConcurrentStreams > 0 {
+		serverOptions = append(serverOptions, grpc.MaxConcurrentStreams(srv.opt.maxConcurrentStreams))
+	}
+	if srv.opt.connectionTimeout > 0 {
+		serverOptions = append(serverOptions, grpc.ConnectionTimeout(srv.opt.connectionTimeout))
+	}
+
+	// load certificate for tls
+	if srv.opt.tlsCertFile != "" {
+		reloader, err := certificate.NewReloader(srv.opt.tlsCertFile, srv.opt.tlsKeyFile, srv.opt.tlsCertificates...)
+		if err != nil {
+			panic(fmt.Errorf("grpc server: %s", err))
+		}
+
+		srv.certificate = reloader
+		srv.opt.tlsConfig = reloader.TLSConfig("h2")
+	}
+
+	if srv.opt.tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(srv.opt.tlsConfig)))
+	}
+
+	srv.serverEngine = grpc.NewServer(append(serverOptions, srv.opt.serverOptions...)...)
+
 	intercept.opt = &srv.opt
 	if h := srv.service.GRPCHandler(); h != nil {
 		h.Register(srv.serverEngine)
 	}
 
+	// standard services, health report all registered services with the same checks
+	if srv.opt.health {
+		var checks []health.Config
+		if hs, ok := svc.(factory.HealthCheckService); ok {
+			checks = hs.HealthChecks()
+		}
+
+		hs, err := newHealthServer(checks, srv.opt.healthInterval)
+		if err != nil {
+			panic(fmt.Errorf("grpc server: %s", err))
+		}
+
+		srv.health = hs
+		for name := range srv.serverEngine.GetServiceInfo() {
+			srv.health.services[name] = struct{}{}
+		}
+		hpb.RegisterHealthServer(srv.serverEngine, srv.health)
+	}
+	if srv.opt.reflection {
+		reflection.Register(srv.serverEngine)
+	}
+	if srv.opt.channelz {
+		channelz.RegisterChannelzServiceToServer(srv.serverEngine)
+	}
+
 	for root, info := range srv.serverEngine.GetServiceInfo() {
 		for _, method := range info.Methods {
 			logger.Green(fmt.Sprintf("[GRPC-METHOD] /%s/%s \t\t[metadata]--> %v", root, method.Name, info.Metadata))
 		}
 	}
 
-	logger.GreenBold(fmt.Sprintf("⇨ GRPC server run at %s\n", tcpURI))
 	return srv
 }
 
 func (r *rpc) Serve() {
-	err := r.serverEngine.Serve(r.listener)
+	listener, err := r.opt.listen()
 	if err != nil {
+		panic(fmt.Errorf("grpc server: %s", err))
+	}
+
+	r.mu.Lock()
+	r.listener = listener
+	r.mu.Unlock()
+	close(r.ready)
+
+	logger.GreenBold(fmt.Sprintf("⇨ GRPC server run at %s://%s\n", listener.Addr().Network(), listener.Addr()))
+	if err = r.serverEngine.Serve(listener); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func (r *rpc) Shutdown(_ context.Context) {
+func (r *rpc) Ready() <-chan struct{} {
+	return r.ready
+}
+
+func (r *rpc) Addr() net.Addr {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.listener == nil {
+		return nil
+	}
+
+	return r.listener.Addr()
+}
+
+// Drain report not serving to clients watching health, rpc are still served until Shutdown
+func (r *rpc) Drain() time.Duration {
+	r.drained.Store(true)
+	if r.health != nil {
+		r.health.draining.Store(true)
+	}
+
+	return r.opt.shutdownDelay
+}
+
+// Shutdown drain the server and wait for pre-stop delay when it is not drained yet, then stop gracefully
+func (r *rpc) Shutdown(ctx context.Context) {
 	defer logger.RedBold("Stopping GRPC Server")
 
+	if !r.drained.Load() && r.Drain() > 0 {
+		logger.YellowBold(fmt.Sprintf("GRPC Server: draining, waiting %s before shutdown", r.opt.shutdownDelay))
+
+		select {
+		case <-time.After(r.opt.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	// graceful stop close the listener of serve
 	r.serverEngine.GracefulStop()
-	_ = r.listener.Close()
+
+	if r.certificate != nil {
+		r.certificate.Close()
+	}
 }
 
 func (r *rpc) Name() string {
@@ -0,0 +1,180 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/health-go/v4"
+	"github.com/vizucode/gokit/factory"
+	"github.com/vizucode/gokit/factory/server"
+	gokitrpc "github.com/vizucode/gokit/factory/server/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	hpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type handler struct{}
+
+func (handler) Register(*grpc.Server) {}
+
+func TestServeRandomPort(t *testing.T) {
+	svc := server.NewService(server.SetServiceName("test"), server.SetGrpcHandler(handler{}))
+
+	srv := gokitrpc.New(svc, gokitrpc.SetTCPHost("127.0.0.1"), gokitrpc.SetTCPPort(0), gokitrpc.SetHealth(true))
+	defer srv.Shutdown(context.Background())
+
+	if srv.Addr() != nil {
+		t.Fatalf("listener must not be bound before serve")
+	}
+
+	go srv.Serve()
+	<-srv.Ready()
+
+	conn, err := grpc.NewClient(srv.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	resp, err := hpb.NewHealthClient(conn).Check(context.Background(), &hpb.HealthCheckRequest{})
+	if err != nil || resp.GetStatus() != hpb.HealthCheckResponse_SERVING {
+		t.Errorf("unexpected health %v %v", resp, err)
+	}
+}
+
+func TestShutdownDelay(t *testing.T) {
+	svc := server.NewService(server.SetServiceName("test"), server.SetGrpcHandler(handler{}))
+
+	srv := gokitrpc.New(svc, gokitrpc.SetTCPHost("127.0.0.1"), gokitrpc.SetTCPPort(0),
+		gokitrpc.SetHealth(true), gokitrpc.SetShutdownDelay(200*time.Millisecond))
+
+	go srv.Serve()
+	<-srv.Ready()
+
+	conn, err := grpc.NewClient(srv.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	go func() {
+		srv.Shutdown(context.Background())
+		close(done)
+	}()
+
+	// server still accept rpc during the delay, health report not serving
+	for deadline := time.Now().Add(time.Second); ; {
+		resp, err := hpb.NewHealthClient(conn).Check(context.Background(), &hpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetStatus() == hpb.HealthCheckResponse_NOT_SERVING {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("health is serving while draining")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case <-done:
+		t.Fatal("server stopped before shutdown delay")
+	default:
+	}
+
+	<-done
+}
+
+func TestShutdownDrained(t *testing.T) {
+	svc := server.NewService(server.SetServiceName("test"), server.SetGrpcHandler(handler{}))
+
+	srv := gokitrpc.New(svc, gokitrpc.SetTCPHost("127.0.0.1"), gokitrpc.SetTCPPort(0), gokitrpc.SetShutdownDelay(time.Hour))
+	go srv.Serve()
+	<-srv.Ready()
+
+	// delay is waited once by the caller of Drain, not again by Shutdown
+	if delay := srv.(factory.DrainingApplication).Drain(); delay != time.Hour {
+		t.Fatalf("drain delay %s", delay)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		srv.Shutdown(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown wait delay again after drain")
+	}
+}
+
+func TestHealthCheckName(t *testing.T) {
+	tests := map[string][]health.Config{
+		"empty":     {{Check: func(context.Context) error { return nil }}},
+		"duplicate": {{Name: "db"}, {Name: "db"}},
+	}
+
+	for name, checks := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if err, _ := recover().(error); err == nil || !strings.HasPrefix(err.Error(), "grpc server:") {
+					t.Fatalf("got %v, want grpc server panic", err)
+				}
+			}()
+
+			svc := server.NewService(server.SetServiceName("test"), server.SetGrpcHandler(handler{}), server.SetHealthChecks(checks...))
+			gokitrpc.New(svc, gokitrpc.SetTCPPort(0), gokitrpc.SetHealth(true))
+		})
+	}
+}
+
+func TestLocalTarget(t *testing.T) {
+	svc := server.NewService(server.SetServiceName("test"), server.SetGrpcHandler(handler{}))
+
+	// unspecified host and random port, the address is known after serve
+	srv := gokitrpc.New(svc, gokitrpc.SetTCPPort(0), gokitrpc.SetHealth(true))
+	defer srv.Shutdown(context.Background())
+
+	target, opts, err := gokitrpc.ResolveTarget("", nil, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go srv.Serve()
+
+	resp, err := hpb.NewHealthClient(conn).Check(context.Background(), &hpb.HealthCheckRequest{})
+	if err != nil || resp.GetStatus() != hpb.HealthCheckResponse_SERVING {
+		t.Errorf("unexpected health %v %v", resp, err)
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	svc := server.NewService(server.SetServiceName("test"), server.SetGrpcHandler(handler{}))
+
+	if _, _, err := gokitrpc.ResolveTarget("", nil, nil); err == nil {
+		t.Error("target is resolved without grpc server")
+	}
+
+	tlsServer := gokitrpc.New(svc, gokitrpc.SetTLSConfig(&tls.Config{}))
+	if _, _, err := gokitrpc.ResolveTarget("", nil, tlsServer); err == nil {
+		t.Error("local target of tls server is resolved")
+	}
+
+	target, opts, err := gokitrpc.ResolveTarget("grpc.internal:443", nil, tlsServer)
+	if err != nil || target != "grpc.internal:443" || len(opts) != 1 {
+		t.Errorf("explicit target resolved to %s with %d options, err %v", target, len(opts), err)
+	}
+}
@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/hellofresh/health-go/v4"
+	"google.golang.org/grpc/codes"
+	hpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthServer grpc.health.v1 service measured with the same dependency checks as rest probes
+type healthServer struct {
+	hpb.UnimplementedHealthServer
+
+	health   *health.Health
+	interval time.Duration
+	// services registered services, empty service name is the whole server
+	services map[string]struct{}
+	// draining report not serving while shutting down
+	draining atomic.Bool
+}
+
+func newHealthServer(checks []health.Config, interval time.Duration) (*healthServer, error) {
+	h, err := health.New(health.WithChecks(checks...))
+	if err != nil {
+		return nil, err
+	}
+
+	return &healthServer{
+		health:   h,
+		interval: interval,
+		services: map[string]struct{}{"": {}},
+	}, nil
+}
+
+func (h *healthServer) Check(ctx context.Context, req *hpb.HealthCheckRequest) (*hpb.HealthCheckResponse, error) {
+	if _, ok := h.services[req.GetService()]; !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	return &hpb.HealthCheckResponse{Status: h.status(ctx)}, nil
+}
+
+// Watch send serving status when changed, measured every interval
+func (h *healthServer) Watch(req *hpb.HealthCheckRequest, stream hpb.Health_WatchServer) error {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	var last hpb.HealthCheckResponse_ServingStatus = -1
+	for {
+		current := hpb.HealthCheckResponse_SERVICE_UNKNOWN
+		if _, ok := h.services[req.GetService()]; ok {
+			current = h.status(stream.Context())
+		}
+
+		if current != last {
+			if err := stream.Send(&hpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthServer) status(ctx context.Context) hpb.HealthCheckResponse_ServingStatus {
+	if h.draining.Load() {
+		return hpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	if h.health.Measure(ctx).Status == health.StatusUnavailable {
+		return hpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	return hpb.HealthCheckResponse_SERVING
+}
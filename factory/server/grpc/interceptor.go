@@ -3,19 +3,26 @@ package grpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/vizucode/gokit/logger"
 	"github.com/vizucode/gokit/tracer"
 	"github.com/vizucode/gokit/types"
-	"github.com/vizucode/gokit/utils/convert"
+	"github.com/vizucode/gokit/utils/certificate"
 	"github.com/vizucode/gokit/utils/errorkit"
 	"github.com/vizucode/gokit/utils/monitoring"
+	"github.com/vizucode/gokit/utils/protolog"
+	"github.com/vizucode/gokit/utils/recovery"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -59,88 +66,131 @@ func (i *interceptor) unaryServerTracerInterceptor(
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (resp interface{}, err error) {
+	if skipLogging(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
 	start := time.Now()
 
+	ctx, in := extractInbound(ctx)
 	dl := logger.DataLogger{
-		RequestId:     logger.GetRequestId(ctx),
+		RequestId:     in.requestId,
+		Ip:            in.ip,
+		Device:        in.userAgent,
+		RequestHeader: in.header,
 		Type:          logger.ServiceType(string(types.GRPC)),
 		Service:       i.serviceName,
 		Host:          i.host,
 		Endpoint:      info.FullMethod,
 		RequestMethod: http.MethodPost,
 		TimeStart:     start,
-		RequestBody:   convert.ToString(req),
+		RequestBody:   i.opt.redactor.String(req),
 	}
 
 	trace, ctx := tracer.StartTraceWithContext(ctx, fmt.Sprintf("GRPC: %s", info.FullMethod))
 	defer func() {
+		var clientErr error
 		if r := recover(); r != nil {
-			err = status.Errorf(codes.Aborted, "%s", r)
+			p := recovery.New(dl.Type.String(), dl.Service, dl.Endpoint, r)
+			p.Record(ctx, trace)
+
+			// the panic is kept on logging, client only receive sanitized internal error
+			err = p
+			clientErr = status.Error(codes.Internal, errorkit.InternalServer)
 		}
-		var sc = http.StatusOK
-		if err != nil {
-			switch er := err.(type) {
-			case *errorkit.ErrorResponse:
-				sc = er.StatusCode()
-			default:
-				c := status.Code(err)
-
-				switch c {
-				case codes.FailedPrecondition, codes.InvalidArgument, codes.Unimplemented:
-					sc = http.StatusBadRequest
-				case codes.Unauthenticated:
-					sc = http.StatusUnauthorized
-				case codes.PermissionDenied:
-					sc = http.StatusForbidden
-				case codes.Unknown, codes.NotFound:
-					sc = http.StatusNotFound
-				case codes.AlreadyExists:
-					sc = http.StatusConflict
-				case codes.Aborted, codes.Canceled, codes.DeadlineExceeded, codes.Internal, codes.DataLoss:
-					sc = http.StatusInternalServerError
-				case codes.OutOfRange:
-					sc = http.StatusBadGateway
-				case codes.Unavailable:
-					sc = http.StatusServiceUnavailable
-				case codes.ResourceExhausted:
-					sc = http.StatusGatewayTimeout
-				default:
-					sc = http.StatusOK
-				}
-			}
+		sc := statusCode(err)
+
+		// response is rendered with protojson and redacted before stored to logging
+		var respBody json.RawMessage
+		if resp != nil {
+			respBody, _ = i.opt.redactor.Marshal(resp)
+			logger.Response(ctx, sc, respBody, err)
+		} else {
+			logger.Response(ctx, sc, nil, err)
 		}
 
-		if sc < 1 {
-			sc = http.StatusInternalServerError
+		// errorkit error is sent as status with details, the original error is kept on logging
+		if err != nil && clientErr == nil {
+			clientErr = errorkit.ToStatus(err).Err()
 		}
-		logger.Response(ctx, sc, resp, err)
 
 		trace.SetError(err)
-		// set error logging
-		respBody, _ := json.Marshal(resp)
-		if len(respBody) > 1000 {
-			trace.Log("response.body.size", len(respBody))
-		} else {
-			trace.Log("response.body", respBody)
+		trace.SetTag("response.body.size", protolog.Size(resp))
+		if len(respBody) <= 1000 {
+			trace.Log("response.body", string(respBody))
 		}
 		trace.SetTag("request_id", dl.RequestId)
 		trace.SetTag("trace_id", tracer.GetTraceID(ctx))
 		trace.Finish()
 		dl.Finalize(ctx)
 		monitoring.PrometheusRecord(dl.StatusCode, dl.RequestMethod, dl.Endpoint, dl.Service, time.Since(dl.TimeStart))
+
+		if clientErr != nil {
+			err = clientErr
+		}
 	}()
 
 	lock := new(logger.Locker)
 	ctx = context.WithValue(ctx, logger.LogKey, lock)
 	lock.Set(logger.RequestId, dl.RequestId)
 
-	reqBody, _ := json.Marshal(req)
-	if len(reqBody) > 1000 {
-		trace.Log("request.body.size", len(reqBody))
-	} else {
-		trace.Log("request.body", len(reqBody))
+	ctx = withPeerIdentity(ctx, trace)
+
+	// echo request id so caller can correlate the logging
+	_ = grpc.SetHeader(ctx, metadata.Pairs(HeaderRequestId, dl.RequestId))
+	setInboundTags(trace, in)
+
+	trace.SetTag("request.body.size", protolog.Size(req))
+	if len(dl.RequestBody) <= 1000 {
+		trace.Log("request.body", dl.RequestBody)
 	}
 
 	resp, err = handler(ctx, req)
 	return
 }
+
+// withPeerIdentity expose verified client certificate of mutual tls to handler and logging
+func withPeerIdentity(ctx context.Context, trace tracer.Tracer) context.Context {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if id, ok := certificate.IdentityFromState(&info.State); ok {
+				ctx = certificate.WithIdentity(ctx, id)
+				logger.Tag(ctx, "client_identity", id.Subject)
+				trace.SetTag("tls.client_subject", id.Subject)
+			}
+		}
+	}
+
+	return ctx
+}
+
+// setInboundTags set tracing tags of caller
+func setInboundTags(trace tracer.Tracer, in inbound) {
+	trace.SetTag("peer.address", in.ip)
+	trace.SetTag("grpc.user_agent", in.userAgent)
+	if in.baggage != "" {
+		trace.SetTag("baggage", in.baggage)
+	}
+}
+
+// skipLogging standard services are not logged, like rest health-check and metrics
+func skipLogging(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.") ||
+		strings.HasPrefix(fullMethod, "/grpc.reflection.") ||
+		strings.HasPrefix(fullMethod, "/grpc.channelz.")
+}
+
+// statusCode convert error of grpc handler into http status code for logging and metrics, from the grpc code
+// received by client so both agree
+func statusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var p *recovery.Panic
+	if errors.As(err, &p) {
+		return http.StatusInternalServerError
+	}
+
+	return errorkit.HTTPFromCode(errorkit.ToStatus(err).Code())
+}
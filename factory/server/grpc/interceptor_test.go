@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/vizucode/gokit/utils/errorkit"
+	"github.com/vizucode/gokit/utils/recovery"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusCode(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want int
+	}{
+		"ok":                 {nil, http.StatusOK},
+		"unknown":            {errors.New("boom"), http.StatusInternalServerError},
+		"resource exhausted": {status.Error(codes.ResourceExhausted, "quota"), http.StatusTooManyRequests},
+		"out of range":       {status.Error(codes.OutOfRange, "page"), http.StatusBadRequest},
+		"not found":          {status.Error(codes.NotFound, "order"), http.StatusNotFound},
+		"wrapped errorkit":   {fmt.Errorf("find order: %w", errorkit.Error(nil, "order not found", http.StatusNotFound)), http.StatusNotFound},
+		"panic":              {&recovery.Panic{Value: "boom"}, http.StatusInternalServerError},
+	}
+
+	for name, c := range cases {
+		if got := statusCode(c.err); got != c.want {
+			t.Errorf("%s: got %d, want %d", name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFdsStart first file descriptor passed by systemd socket activation
+const listenFdsStart = 3
+
+// listen bind listener of server, order: listener set by option, systemd socket, unix socket, tcp
+func (o *option) listen() (net.Listener, error) {
+	if o.listener != nil {
+		return o.listener, nil
+	}
+
+	if o.systemdSocket {
+		ln, err := systemdListener(o.systemdSocketName)
+		if err != nil {
+			return nil, err
+		}
+		if ln != nil {
+			return ln, nil
+		}
+	}
+
+	if o.unixSocket != "" {
+		// remove stale socket file of previous process
+		if err := os.Remove(o.unixSocket); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		return net.Listen("unix", o.unixSocket)
+	}
+
+	return net.Listen("tcp", net.JoinHostPort(o.tcpHost, o.tcpPort))
+}
+
+// systemdListener inherit listener passed by systemd socket activation, nil when the process is not activated.
+// Name select socket by FileDescriptorName of the socket unit, empty take the first socket
+func systemdListener(name string) (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, nil
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < fds; i++ {
+		if name != "" && (i >= len(names) || names[i] != name) {
+			continue
+		}
+
+		file := os.NewFile(uintptr(listenFdsStart+i), fmt.Sprintf("systemd-socket-%d", i))
+		ln, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("systemd socket %d: %w", listenFdsStart+i, err)
+		}
+
+		return ln, nil
+	}
+
+	return nil, fmt.Errorf("systemd socket %q not found in LISTEN_FDNAMES", name)
+}
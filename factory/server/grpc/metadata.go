@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// HeaderRequestId metadata key of request id, echoed in response header
+const HeaderRequestId = "x-request-id"
+
+// inbound request data of caller from grpc metadata and peer
+type inbound struct {
+	requestId string
+	userAgent string
+	ip        string
+	header    string
+	baggage   string
+}
+
+// metadataCarrier adapt grpc metadata to propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if v := metadata.MD(mc).Get(key); len(v) > 0 {
+		return v[0]
+	}
+
+	return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// extractInbound read request id, trace context, baggage, user agent and peer address of caller,
+// trace context and baggage are set into context so the span continue the caller trace
+func extractInbound(ctx context.Context) (context.Context, inbound) {
+	var in inbound
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	carrier := metadataCarrier(md)
+
+	propagator := propagation.NewCompositeTextMapPropagator(otel.GetTextMapPropagator(), propagation.Baggage{})
+	ctx = propagator.Extract(ctx, carrier)
+
+	in.requestId = carrier.Get(HeaderRequestId)
+	if in.requestId == "" {
+		in.requestId = uuid.NewString()
+	}
+	in.userAgent = carrier.Get("user-agent")
+	in.baggage = baggage.FromContext(ctx).String()
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		in.ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(in.ip); err == nil {
+			in.ip = host
+		}
+	}
+
+	header, _ := json.Marshal(md)
+	in.header = string(header)
+
+	return ctx, in
+}
@@ -1,9 +1,17 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
+	"strings"
+	"time"
 
+	"github.com/vizucode/gokit/utils/certificate"
 	"github.com/vizucode/gokit/utils/env"
+	"github.com/vizucode/gokit/utils/protolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 // OptionFunc setter to set grpc option
@@ -13,15 +21,80 @@ type OptionFunc func(*option)
 type option struct {
 	tcpPort string
 	tcpHost string
+
+	// listener sources tried before tcp, bound when the server is served
+	listener          net.Listener
+	unixSocket        string
+	systemdSocket     bool
+	systemdSocketName string
+
+	// tlsConfig serve grpc over tls, certificate files are reloaded on change when set with SetTLS
+	tlsConfig       *tls.Config
+	tlsCertFile     string
+	tlsKeyFile      string
+	tlsCertificates []certificate.OptionFunc
+
+	keepAliveEnforce keepalive.EnforcementPolicy
+	keepAliveServer  keepalive.ServerParameters
+
+	// server tuning, zero value use grpc default
+	maxRecvMsgSize       int
+	maxSendMsgSize       int
+	maxConcurrentStreams uint32
+	connectionTimeout    time.Duration
+
+	// interceptors registered before and after logging interceptor
+	unaryBeforeLogger  []grpc.UnaryServerInterceptor
+	unaryAfterLogger   []grpc.UnaryServerInterceptor
+	streamBeforeLogger []grpc.StreamServerInterceptor
+	streamAfterLogger  []grpc.StreamServerInterceptor
+
+	// standard services, togglable per environment
+	health         bool
+	healthInterval time.Duration
+	reflection     bool
+	channelz       bool
+
+	// shutdownDelay wait before graceful stop so clients watching health notice not serving
+	shutdownDelay time.Duration
+
+	// redactor render logged request and response, redact fields with debug_redact option or listed path
+	redactor *protolog.Redactor
+
+	// serverOptions applied last, overriding options set by the server
+	serverOptions []grpc.ServerOption
 }
 
 func defaultOption() option {
 	return option{
-		tcpPort: fmt.Sprintf(":%d", env.GetInteger("GRPC_PORT", 6060)),
+		tcpPort:           fmt.Sprintf("%d", env.GetInteger("GRPC_PORT", 6060)),
+		unixSocket:        env.GetString("GRPC_UNIX_SOCKET", ""),
+		systemdSocket:     env.GetBool("GRPC_SYSTEMD_SOCKET", true),
+		systemdSocketName: env.GetString("GRPC_SYSTEMD_SOCKET_NAME", ""),
+		keepAliveEnforce: keepalive.EnforcementPolicy{
+			MinTime:             env.GetDuration("GRPC_MIN_TIME", time.Duration(10)*time.Second),
+			PermitWithoutStream: true,
+		},
+		keepAliveServer: keepalive.ServerParameters{
+			MaxConnectionIdle:     env.GetDuration("GRPC_MAX_CONNECTION_IDLE_DURATION", time.Duration(10)*time.Second), // if a client idle for 10s, send a go away
+			MaxConnectionAgeGrace: env.GetDuration("GRPC_MAX_CONNECTION_AGE_GRACE", time.Duration(10)*time.Second),     // allows 10s for pending RPCs to complete before forcibly closing connections
+			Time:                  env.GetDuration("GRPC_TIME_PING_CLIENT", time.Duration(10)*time.Second),             // ping the client if it's idle for 10s to ensure the connection is still alive
+			Timeout:               env.GetDuration("GRPC_TIMEOUT", time.Duration(10)*time.Second),                      // wait 10s for the ping ack before assuming the connection is dead
+		},
+		maxRecvMsgSize:       env.GetInteger("GRPC_MAX_RECV_MSG_SIZE", 0),
+		maxSendMsgSize:       env.GetInteger("GRPC_MAX_SEND_MSG_SIZE", 0),
+		maxConcurrentStreams: uint32(env.GetInteger("GRPC_MAX_CONCURRENT_STREAMS", 0)),
+		connectionTimeout:    env.GetDuration("GRPC_CONNECTION_TIMEOUT", 0),
+		health:               env.GetBool("GRPC_HEALTH", false),
+		healthInterval:       env.GetDuration("GRPC_HEALTH_WATCH_INTERVAL", 5*time.Second),
+		reflection:           env.GetBool("GRPC_REFLECTION", false),
+		channelz:             env.GetBool("GRPC_CHANNELZ", false),
+		shutdownDelay:        env.GetDuration("GRPC_SHUTDOWN_DELAY", 0),
+		redactor:             protolog.New(strings.Split(env.GetString("GRPC_LOG_REDACT_PATHS", ""), ",")...),
 	}
 }
 
-// SetTCPPort set tcp port
+// SetTCPPort set tcp port, zero bind random port reported by Addr
 func SetTCPPort(port int) OptionFunc {
 	return func(o *option) {
 		o.tcpPort = fmt.Sprintf("%d", port)
@@ -33,3 +106,167 @@ func SetTCPHost(host string) OptionFunc {
 		o.tcpHost = host
 	}
 }
+
+// SetUnixSocket serve on unix domain socket instead of tcp, stale socket file is removed before binding
+func SetUnixSocket(path string) OptionFunc {
+	return func(o *option) {
+		o.unixSocket = path
+	}
+}
+
+// SetListener serve on the given listener instead of binding one, e.g. bufconn in tests
+func SetListener(listener net.Listener) OptionFunc {
+	return func(o *option) {
+		o.listener = listener
+	}
+}
+
+// SetSystemdSocket inherit listener passed by systemd socket activation when the process is activated, enabled by default.
+// Name select socket by FileDescriptorName of the socket unit, empty take the first socket
+func SetSystemdSocket(enable bool, name string) OptionFunc {
+	return func(o *option) {
+		o.systemdSocket = enable
+		o.systemdSocketName = name
+	}
+}
+
+// SetTLS serve grpc over tls with certificate and key files, the files are reloaded when changed on disk.
+// Use certificate.SetClientCA to verify client certificate (mutual tls)
+func SetTLS(certFile, keyFile string, opts ...certificate.OptionFunc) OptionFunc {
+	return func(o *option) {
+		o.tlsCertFile = certFile
+		o.tlsKeyFile = keyFile
+		o.tlsCertificates = opts
+	}
+}
+
+// SetTLSConfig serve grpc over tls with static tls configuration
+func SetTLSConfig(tlsConfig *tls.Config) OptionFunc {
+	return func(o *option) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// SetKeepaliveParams set keepalive parameters of server
+func SetKeepaliveParams(params keepalive.ServerParameters) OptionFunc {
+	return func(o *option) {
+		o.keepAliveServer = params
+	}
+}
+
+// SetKeepaliveEnforcementPolicy set keepalive enforcement policy of server
+func SetKeepaliveEnforcementPolicy(policy keepalive.EnforcementPolicy) OptionFunc {
+	return func(o *option) {
+		o.keepAliveEnforce = policy
+	}
+}
+
+// SetMaxRecvMsgSize set max message size in bytes the server can receive
+func SetMaxRecvMsgSize(size int) OptionFunc {
+	return func(o *option) {
+		o.maxRecvMsgSize = size
+	}
+}
+
+// SetMaxSendMsgSize set max message size in bytes the server can send
+func SetMaxSendMsgSize(size int) OptionFunc {
+	return func(o *option) {
+		o.maxSendMsgSize = size
+	}
+}
+
+// SetMaxConcurrentStreams set max concurrent streams of each client connection
+func SetMaxConcurrentStreams(streams uint32) OptionFunc {
+	return func(o *option) {
+		o.maxConcurrentStreams = streams
+	}
+}
+
+// SetConnectionTimeout set timeout of connection establishment, include tls handshake
+func SetConnectionTimeout(timeout time.Duration) OptionFunc {
+	return func(o *option) {
+		o.connectionTimeout = timeout
+	}
+}
+
+// SetUnaryInterceptorBeforeLogger add unary interceptors run before logging interceptor,
+// the interceptors are not logged, traced nor recovered from panic
+func SetUnaryInterceptorBeforeLogger(interceptors ...grpc.UnaryServerInterceptor) OptionFunc {
+	return func(o *option) {
+		o.unaryBeforeLogger = append(o.unaryBeforeLogger, interceptors...)
+	}
+}
+
+// SetUnaryInterceptorAfterLogger add unary interceptors run after logging interceptor, e.g. authentication
+func SetUnaryInterceptorAfterLogger(interceptors ...grpc.UnaryServerInterceptor) OptionFunc {
+	return func(o *option) {
+		o.unaryAfterLogger = append(o.unaryAfterLogger, interceptors...)
+	}
+}
+
+// SetStreamInterceptorBeforeLogger add stream interceptors run before logging interceptor,
+// the interceptors are not logged, traced nor recovered from panic
+func SetStreamInterceptorBeforeLogger(interceptors ...grpc.StreamServerInterceptor) OptionFunc {
+	return func(o *option) {
+		o.streamBeforeLogger = append(o.streamBeforeLogger, interceptors...)
+	}
+}
+
+// SetStreamInterceptorAfterLogger add stream interceptors run after logging interceptor, e.g. authentication
+func SetStreamInterceptorAfterLogger(interceptors ...grpc.StreamServerInterceptor) OptionFunc {
+	return func(o *option) {
+		o.streamAfterLogger = append(o.streamAfterLogger, interceptors...)
+	}
+}
+
+// SetServerOptions add grpc server options, applied after options set by the server so it can override them.
+// Use interceptor setters instead of grpc.UnaryInterceptor and grpc.StreamInterceptor
+func SetServerOptions(opts ...grpc.ServerOption) OptionFunc {
+	return func(o *option) {
+		o.serverOptions = append(o.serverOptions, opts...)
+	}
+}
+
+// SetHealth register grpc.health.v1 service, measured with health checks of service, disabled by default
+func SetHealth(enable bool) OptionFunc {
+	return func(o *option) {
+		o.health = enable
+	}
+}
+
+// SetHealthWatchInterval set interval of measuring health checks for health watch stream
+func SetHealthWatchInterval(interval time.Duration) OptionFunc {
+	return func(o *option) {
+		o.healthInterval = interval
+	}
+}
+
+// SetReflection register server reflection service, used by grpcurl
+func SetReflection(enable bool) OptionFunc {
+	return func(o *option) {
+		o.reflection = enable
+	}
+}
+
+// SetChannelz register channelz service for debugging connections
+func SetChannelz(enable bool) OptionFunc {
+	return func(o *option) {
+		o.channelz = enable
+	}
+}
+
+// SetShutdownDelay set pre-stop delay, health report not serving during the delay before server stop accepting rpc.
+// Delays of rest and grpc servers are not added up, the longest one is waited once on service shutdown
+func SetShutdownDelay(shutdownDelay time.Duration) OptionFunc {
+	return func(o *option) {
+		o.shutdownDelay = shutdownDelay
+	}
+}
+
+// SetLogRedactPaths redact request and response fields on logging and tracing, path is proto field names
+// joined by dot, e.g. user.password, or field name matched at any depth. Fields with debug_redact option are always redacted
+func SetLogRedactPaths(paths ...string) OptionFunc {
+	return func(o *option) {
+		o.redactor = protolog.New(paths...)
+	}
+}
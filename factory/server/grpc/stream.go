@@ -0,0 +1,160 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/tracer"
+	"github.com/vizucode/gokit/types"
+	"github.com/vizucode/gokit/utils/errorkit"
+	"github.com/vizucode/gokit/utils/monitoring"
+	"github.com/vizucode/gokit/utils/recovery"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// statusClientClosedRequest status code of stream cancelled by client
+const statusClientClosedRequest = 499
+
+// serverStream wrap grpc.ServerStream to carry logging context and count messages
+type serverStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received atomic.Int64
+	sent     atomic.Int64
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Add(1)
+	}
+
+	return err
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+
+	return err
+}
+
+func (i *interceptor) chainStreamServer(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	n := len(interceptors)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		chainer := func(currentInterceptor grpc.StreamServerInterceptor, currentHandler grpc.StreamHandler) grpc.StreamHandler {
+			return func(currentSrv interface{}, currentStream grpc.ServerStream) error {
+				return currentInterceptor(currentSrv, currentStream, info, currentHandler)
+			}
+		}
+
+		chainedHandler := handler
+		for i := n - 1; i >= 0; i-- {
+			chainedHandler = chainer(interceptors[i], chainedHandler)
+		}
+
+		return chainedHandler(srv, ss)
+	}
+}
+
+// streamServerTracerInterceptor log and trace the whole lifetime of server-streaming, client-streaming and bidi stream
+func (i *interceptor) streamServerTracerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	if skipLogging(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	start := time.Now()
+
+	ctx, in := extractInbound(ss.Context())
+	dl := logger.DataLogger{
+		RequestId:     in.requestId,
+		Ip:            in.ip,
+		Device:        in.userAgent,
+		RequestHeader: in.header,
+		Type:          logger.ServiceType(string(types.GRPC)),
+		Service:       i.serviceName,
+		Host:          i.host,
+		Endpoint:      info.FullMethod,
+		RequestMethod: http.MethodPost,
+		TimeStart:     start,
+	}
+
+	stream := &serverStream{ServerStream: ss}
+	trace, ctx := tracer.StartTraceWithContext(ctx, fmt.Sprintf("GRPC-STREAM: %s", info.FullMethod))
+	defer func() {
+		var clientErr error
+		if r := recover(); r != nil {
+			p := recovery.New(dl.Type.String(), dl.Service, dl.Endpoint, r)
+			p.Record(ctx, trace)
+
+			// the panic is kept on logging, client only receive sanitized internal error
+			err = p
+			clientErr = status.Error(codes.Internal, errorkit.InternalServer)
+		}
+
+		sc := statusCode(err)
+		// stream context is cancelled when client gone before the handler return
+		if errors.Is(ctx.Err(), context.Canceled) && (err == nil || status.Code(err) == codes.Canceled) {
+			sc = statusClientClosedRequest
+			logger.Tag(ctx, "cancelled", true)
+			trace.SetTag("grpc.cancelled", true)
+		}
+
+		// errorkit error is sent as status with details, the original error is kept on logging
+		if err != nil && clientErr == nil {
+			clientErr = errorkit.ToStatus(err).Err()
+		}
+
+		resp := fmt.Sprintf("received %d messages, sent %d messages", stream.received.Load(), stream.sent.Load())
+		logger.Response(ctx, sc, resp, err)
+
+		trace.SetError(err)
+		trace.SetTag("grpc.stream.client", info.IsClientStream)
+		trace.SetTag("grpc.stream.server", info.IsServerStream)
+		trace.SetTag("message.received", stream.received.Load())
+		trace.SetTag("message.sent", stream.sent.Load())
+		trace.SetTag("request_id", dl.RequestId)
+		trace.SetTag("trace_id", tracer.GetTraceID(ctx))
+		trace.Finish()
+		dl.Finalize(ctx)
+		monitoring.PrometheusRecord(dl.StatusCode, dl.RequestMethod, dl.Endpoint, dl.Service, time.Since(dl.TimeStart))
+
+		if clientErr != nil {
+			err = clientErr
+		}
+	}()
+
+	lock := new(logger.Locker)
+	ctx = context.WithValue(ctx, logger.LogKey, lock)
+	lock.Set(logger.RequestId, dl.RequestId)
+
+	ctx = withPeerIdentity(ctx, trace)
+
+	// echo request id so caller can correlate the logging
+	_ = ss.SetHeader(metadata.Pairs(HeaderRequestId, dl.RequestId))
+	setInboundTags(trace, in)
+
+	stream.ctx = ctx
+	return handler(srv, stream)
+}
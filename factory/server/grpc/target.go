@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// localTarget target of client in the same process, the connection is made by dialer of the server
+const localTarget = "passthrough:///localhost"
+
+// contextDialer in-memory listener which dial itself, e.g. bufconn
+type contextDialer interface {
+	DialContext(ctx context.Context) (net.Conn, error)
+}
+
+// LocalTarget target and dial options to reach the server from the same process, e.g. grpc-gateway.
+// Address is taken from the bound listener when the client connect, so unix socket, systemd socket and port zero work.
+// Server over tls can not be derived since client credentials are unknown
+func (r *rpc) LocalTarget() (string, []grpc.DialOption, error) {
+	if r.opt.tlsConfig != nil {
+		return "", nil, errors.New("grpc server: local target of tls server can not be derived, set target and dial options")
+	}
+
+	return localTarget, []grpc.DialOption{
+		grpc.WithContextDialer(r.dialLocal),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, nil
+}
+
+// dialLocal wait until the listener is bound then dial its address, unspecified host is dialed on loopback
+func (r *rpc) dialLocal(ctx context.Context, _ string) (net.Conn, error) {
+	select {
+	case <-r.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	listener := r.listener
+	r.mu.Unlock()
+
+	if ln, ok := listener.(contextDialer); ok {
+		return ln.DialContext(ctx)
+	}
+
+	addr := listener.Addr()
+	address := addr.String()
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		if a.IP.IsUnspecified() {
+			address = net.JoinHostPort("127.0.0.1", fmt.Sprintf("%d", a.Port))
+		}
+	case *net.UnixAddr:
+	default:
+		return nil, fmt.Errorf("grpc server: can not dial listener of network %s", addr.Network())
+	}
+
+	var d net.Dialer
+	return d.DialContext(ctx, addr.Network(), address)
+}
+
+// ResolveTarget target and dial options of proxy to grpc server. Target set explicitly is used with the dial options,
+// insecure when no dial option is set. Otherwise the local target of server is used with the dial options appended
+func ResolveTarget(target string, dialOptions []grpc.DialOption, srv Server) (string, []grpc.DialOption, error) {
+	if target != "" {
+		if len(dialOptions) == 0 {
+			dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+		}
+
+		return target, dialOptions, nil
+	}
+
+	if srv == nil {
+		return "", nil, errors.New("grpc server is not served by the service, set target and dial options")
+	}
+
+	local, opts, err := srv.LocalTarget()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return local, append(opts, dialOptions...), nil
+}
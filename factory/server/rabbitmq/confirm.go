@@ -0,0 +1,191 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+)
+
+// headerPublishId header identifying published message, returned message is matched to its publish by it
+const headerPublishId = "x-publish-id"
+
+var (
+	// errConfirmNack broker refused the published message
+	errConfirmNack = errors.New("rabbitmq: message is nacked by broker")
+	// errConfirmTimeout confirmation is not received in time, the message may or may not be stored
+	errConfirmTimeout = errors.New("rabbitmq: confirmation timeout")
+	// errUnroutable message is returned by broker, no queue is bound to the routing key, e.g. retry queue
+	// of another retry policy is not declared or the queue is deleted
+	errUnroutable = errors.New("rabbitmq: message is unroutable")
+)
+
+// confirmChannel channel of confirmPublisher, implemented by *amqp.Channel
+type confirmChannel interface {
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyReturn(ret chan amqp.Return) chan amqp.Return
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// confirmWaiter publish waiting its confirmation, returned message is recorded before the confirmation arrive
+type confirmWaiter struct {
+	id       string
+	returned bool
+	done     chan error
+}
+
+// confirmPublisher publish mandatory message on its own channel in confirm mode, shared by every queue of the worker.
+// Concurrent publish wait their own delivery tag, the channel is opened again after it is closed
+type confirmPublisher struct {
+	open    func() (confirmChannel, error)
+	timeout time.Duration
+
+	mu      sync.Mutex
+	ch      confirmChannel
+	seq     uint64
+	waiting map[uint64]*confirmWaiter
+	byId    map[string]*confirmWaiter
+}
+
+func newConfirmPublisher(open func() (confirmChannel, error), timeout time.Duration) *confirmPublisher {
+	return &confirmPublisher{open: open, timeout: timeout}
+}
+
+// publish message and wait until broker confirm it, message which is not routed to any queue fail
+func (p *confirmPublisher) publish(exchange, key string, msg amqp.Publishing) error {
+	p.mu.Lock()
+	if p.ch == nil {
+		if err := p.reset(); err != nil {
+			p.mu.Unlock()
+			return err
+		}
+	}
+
+	w := &confirmWaiter{id: uuid.NewString(), done: make(chan error, 1)}
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[headerPublishId] = w.id
+	msg.Headers = headers
+
+	if err := p.ch.Publish(exchange, key, true, false, msg); err != nil {
+		p.mu.Unlock()
+		return err
+	}
+
+	// delivery tag is counted by channel from one, the lock keep it in step with publish
+	p.seq++
+	tag, waiting, byId := p.seq, p.waiting, p.byId
+	waiting[tag], byId[w.id] = w, w
+	p.mu.Unlock()
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	select {
+	case err, ok := <-w.done:
+		if !ok {
+			return amqp.ErrClosed
+		}
+		return err
+	case <-timer.C:
+		p.mu.Lock()
+		delete(waiting, tag)
+		delete(byId, w.id)
+		p.mu.Unlock()
+		return errConfirmTimeout
+	}
+}
+
+// reset open channel in confirm mode, must be called with lock held
+func (p *confirmPublisher) reset() error {
+	ch, err := p.open()
+	if err != nil {
+		return err
+	}
+
+	if err = ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		return fmt.Errorf("rabbitmq: confirm mode: %w", err)
+	}
+
+	p.ch, p.seq = ch, 0
+	p.waiting, p.byId = make(map[uint64]*confirmWaiter), make(map[string]*confirmWaiter)
+
+	// unbuffered so the next notification is not sent by amqp before the previous one is received,
+	// returned message always precede its confirmation
+	go p.dispatch(ch, ch.NotifyPublish(make(chan amqp.Confirmation)), ch.NotifyReturn(make(chan amqp.Return)), p.waiting, p.byId)
+
+	return nil
+}
+
+// dispatch confirmation to waiting publish, publish still waiting when the channel is closed fail
+func (p *confirmPublisher) dispatch(ch confirmChannel, confirms <-chan amqp.Confirmation, returns <-chan amqp.Return,
+	waiting map[uint64]*confirmWaiter, byId map[string]*confirmWaiter) {
+	for confirms != nil || returns != nil {
+		select {
+		case c, ok := <-confirms:
+			if !ok {
+				confirms = nil
+				continue
+			}
+
+			p.mu.Lock()
+			if w, ok := waiting[c.DeliveryTag]; ok {
+				delete(waiting, c.DeliveryTag)
+				delete(byId, w.id)
+
+				switch {
+				case w.returned:
+					w.done <- errUnroutable
+				case !c.Ack:
+					w.done <- errConfirmNack
+				default:
+					w.done <- nil
+				}
+			}
+			p.mu.Unlock()
+		case r, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+
+			id, _ := r.Headers[headerPublishId].(string)
+			p.mu.Lock()
+			if w, ok := byId[id]; ok {
+				w.returned = true
+			}
+			p.mu.Unlock()
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for tag, w := range waiting {
+		delete(waiting, tag)
+		delete(byId, w.id)
+		close(w.done)
+	}
+	if p.ch == ch {
+		p.ch = nil
+	}
+}
+
+// close channel of publisher, closed without lock since dispatch need it to receive pending notification
+func (p *confirmPublisher) close() {
+	p.mu.Lock()
+	ch := p.ch
+	p.mu.Unlock()
+
+	if ch != nil {
+		_ = ch.Close()
+	}
+}
@@ -0,0 +1,199 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/types"
+	"github.com/vizucode/gokit/utils/backoff"
+)
+
+// connection open channel of broker, implemented by rabbitmqc.RabbitMQ which recover lost connection
+type connection interface {
+	Channel(ctx context.Context) (*amqp.Channel, error)
+}
+
+// staticChannel connection of broker configured with a single channel, can not be recovered.
+// Retried message is published on the channel in confirm mode, so it must not be used by other publisher
+type staticChannel struct {
+	ch *amqp.Channel
+}
+
+func (s staticChannel) Channel(_ context.Context) (*amqp.Channel, error) {
+	return s.ch, nil
+}
+
+// queueConsumer consume a queue on its own channel, message is processed by a pool of goroutines
+// and the channel is recovered independently from other queues
+type queueConsumer struct {
+	worker   *rabbitMqWorker
+	handler  types.BrokerHandler
+	topology topology
+	prefetch int
+	// semaphore bound running handlers of the queue
+	semaphore chan struct{}
+
+	mu         sync.Mutex
+	ch         *amqp.Channel
+	deliveries <-chan amqp.Delivery
+	closed     chan *amqp.Error
+}
+
+// newQueueConsumer concurrency and prefetch of handler, default to worker options
+func (r *rabbitMqWorker) newQueueConsumer(handler types.BrokerHandler) (*queueConsumer, error) {
+	t, err := newTopology(handler)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := handler.Concurrency
+	if concurrency <= 0 {
+		concurrency = r.opt.maxGoroutines
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	prefetch := handler.Prefetch
+	if prefetch <= 0 {
+		prefetch = r.opt.prefetch
+	}
+	if prefetch <= 0 {
+		prefetch = concurrency
+	}
+
+	return &queueConsumer{
+		worker:    r,
+		handler:   handler,
+		topology:  t,
+		prefetch:  prefetch,
+		semaphore: make(chan struct{}, concurrency),
+	}, nil
+}
+
+// consume open channel, set prefetch, declare queue and start consuming, declared again on every recovered channel
+func (q *queueConsumer) consume() error {
+	r := q.worker
+
+	ch, err := r.conn.Channel(r.stop)
+	if err != nil {
+		return err
+	}
+
+	if err = ch.Qos(q.prefetch, 0, false); err != nil {
+		_ = ch.Close()
+		return fmt.Errorf("error setting prefetch: %s", err)
+	}
+
+	if err = r.setupRetry(ch, q.topology); err != nil {
+		_ = ch.Close()
+		return err
+	}
+
+	deliveries, err := setupQueueConfig(ch, q.topology)
+	if err != nil {
+		_ = ch.Close()
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.ch, q.deliveries = ch, deliveries
+	q.closed = ch.NotifyClose(make(chan *amqp.Error, 1))
+
+	return nil
+}
+
+// resume consume on new channel after the channel is closed, retried with backoff until shutdown
+func (q *queueConsumer) resume(cause error) {
+	r := q.worker
+	logger.Red(fmt.Sprintf("[RABBITMQ-CONSUMER] (queue): %s channel closed: %v", q.handler.Queue, cause))
+
+	q.close()
+
+	for attempt := 0; r.stop.Err() == nil; attempt++ {
+		err := q.consume()
+		if err == nil {
+			logger.Green(fmt.Sprintf("[RABBITMQ-CONSUMER] (queue): %s consumer resumed", q.handler.Queue))
+			return
+		}
+		if r.stop.Err() != nil {
+			return
+		}
+		logger.Red(fmt.Sprintf("[RABBITMQ-CONSUMER] (queue): %s resume attempt %d failed: %s", q.handler.Queue, attempt+1, err))
+
+		timer := time.NewTimer(backoff.Jitter(backoff.Exponential(attempt, r.opt.recoverMinBackoff, r.opt.recoverMaxBackoff)))
+		select {
+		case <-r.stop.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// serve dispatch deliveries to the pool until shutdown, delivery waiting for free goroutine is
+// left unacked and redelivered by broker when the channel is closed
+func (q *queueConsumer) serve() {
+	r := q.worker
+
+	for r.stop.Err() == nil {
+		q.mu.Lock()
+		deliveries, closed := q.deliveries, q.closed
+		q.mu.Unlock()
+
+	consume:
+		for {
+			select {
+			case <-r.stop.Done():
+				return
+			case err := <-closed:
+				// channel is closed by broker or lost connection, deliveries are closed as well
+				var cause error = amqp.ErrClosed
+				if err != nil {
+					cause = err
+				}
+				q.resume(cause)
+				break consume
+			case message, ok := <-deliveries:
+				if !ok {
+					q.resume(amqp.ErrClosed)
+					break consume
+				}
+
+				select {
+				case q.semaphore <- struct{}{}:
+				case <-r.stop.Done():
+					return
+				}
+
+				// shutdown may start while waiting semaphore, message is left unacked and redelivered
+				if !r.track() {
+					<-q.semaphore
+					return
+				}
+
+				go func() {
+					defer func() {
+						<-q.semaphore
+						r.wg.Done()
+					}()
+					r.processMessage(message, q.handler, q.topology)
+				}()
+			}
+		}
+	}
+}
+
+func (q *queueConsumer) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.ch != nil {
+		_ = q.ch.Close()
+	}
+}
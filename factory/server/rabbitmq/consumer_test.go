@@ -0,0 +1,37 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTrackAfterShutdown(t *testing.T) {
+	r := newRetryWorker(&fakeChannel{ack: true})
+	r.ctx, r.cancelFunc = context.WithCancel(context.Background())
+	r.stop, r.stopFunc = context.WithCancel(context.Background())
+
+	if !r.track() {
+		t.Fatal("handler is not tracked before shutdown")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.Shutdown(context.Background())
+		close(done)
+	}()
+
+	// shutdown wait running handler
+	select {
+	case <-done:
+		t.Fatal("shutdown returned while handler is running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if r.track() {
+		t.Fatal("handler is tracked after shutdown started")
+	}
+
+	r.wg.Done()
+	<-done
+}
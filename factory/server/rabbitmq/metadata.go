@@ -0,0 +1,50 @@
+package rabbitmq
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// HeaderRequestId message header of request id, set by rabbitmqc publisher
+const HeaderRequestId = "x-request-id"
+
+// tableCarrier adapt amqp headers to propagation.TextMapCarrier
+type tableCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = tableCarrier{}
+
+func (t tableCarrier) Get(key string) string {
+	v, _ := t[key].(string)
+	return v
+}
+
+func (t tableCarrier) Set(key, value string) {
+	t[key] = value
+}
+
+func (t tableCarrier) Keys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// extractInbound read request id and trace context of publisher from message headers, trace context is set
+// into context so the span continue the publisher trace. Request id is generated when it is absent
+func extractInbound(ctx context.Context, headers amqp.Table) (context.Context, string) {
+	carrier := tableCarrier(headers)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+
+	requestId := carrier.Get(HeaderRequestId)
+	if requestId == "" {
+		requestId = uuid.NewString()
+	}
+
+	return ctx, requestId
+}
@@ -0,0 +1,38 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestExtractInbound(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	published := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	headers := amqp.Table{HeaderRequestId: "req-1"}
+	otel.GetTextMapPropagator().Inject(published, tableCarrier(headers))
+
+	ctx, requestId := extractInbound(context.Background(), headers)
+	if requestId != "req-1" {
+		t.Errorf("request id %q, want req-1", requestId)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.TraceID() != traceId || !sc.IsRemote() {
+		t.Errorf("trace is not continued, got %v", sc)
+	}
+
+	if _, requestId = extractInbound(context.Background(), nil); requestId == "" {
+		t.Error("request id is not generated")
+	}
+}
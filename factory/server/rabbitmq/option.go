@@ -1,15 +1,35 @@
 package rabbitmq
 
-import "github.com/vizucode/gokit/utils/env"
+import (
+	"time"
+
+	"github.com/vizucode/gokit/utils/env"
+)
 
 type option struct {
 	exchangeName  string
-	queue         string
 	broker        string
 	maxGoroutines int
 	debugMode     bool
-	isAutoAck     bool
 	serviceName   string
+
+	// prefetch unacknowledged deliveries of each queue, zero follow concurrency of the queue
+	prefetch int
+	// dryRun validate topology against broker without declaring and consuming
+	dryRun bool
+
+	recoverMinBackoff time.Duration
+	recoverMaxBackoff time.Duration
+
+	// maxRetry number of retry of failed message before routed to dead-letter exchange
+	maxRetry        int
+	retryMinBackoff time.Duration
+	retryMaxBackoff time.Duration
+	// retryConfirmTimeout maximum duration to wait confirmation of retried message before it is requeued
+	retryConfirmTimeout time.Duration
+	// deadLetterExchange exchange of message which retry is exhausted, empty reject the message.
+	// Queue with its own dead-letter exchange reject the message to it instead
+	deadLetterExchange string
 }
 
 type OptionFunc func(*option)
@@ -17,17 +37,35 @@ type OptionFunc func(*option)
 func getDefaultOption() option {
 	return option{
 		maxGoroutines: env.GetInteger("BROKER_MAX_GOROUTINES", 20),
+		prefetch:      env.GetInteger("BROKER_PREFETCH"),
+		dryRun:        env.GetBool("BROKER_TOPOLOGY_DRY_RUN"),
 		debugMode:     env.GetBool("DEBUG_MODE"),
+
+		recoverMinBackoff: env.GetDuration("BROKER_RECOVER_MIN_BACKOFF", 500*time.Millisecond),
+		recoverMaxBackoff: env.GetDuration("BROKER_RECOVER_MAX_BACKOFF", 30*time.Second),
+
+		maxRetry:            env.GetInteger("BROKER_MAX_RETRY", 3),
+		retryMinBackoff:     env.GetDuration("BROKER_RETRY_MIN_BACKOFF", time.Second),
+		retryMaxBackoff:     env.GetDuration("BROKER_RETRY_MAX_BACKOFF", time.Minute),
+		retryConfirmTimeout: env.GetDuration("BROKER_RETRY_CONFIRM_TIMEOUT", 5*time.Second),
+		deadLetterExchange:  env.GetString("BROKER_DEAD_LETTER_EXCHANGE", "dead-letter"),
 	}
 }
 
-// SetMaxGoroutines option func
+// SetMaxGoroutines option func, default concurrency of each queue
 func SetMaxGoroutines(maxGoroutines int) OptionFunc {
 	return func(o *option) {
 		o.maxGoroutines = maxGoroutines
 	}
 }
 
+// SetPrefetch option func, default prefetch count of each queue
+func SetPrefetch(prefetch int) OptionFunc {
+	return func(o *option) {
+		o.prefetch = prefetch
+	}
+}
+
 // SetDebugMode option func
 func SetDebugMode(debugMode bool) OptionFunc {
 	return func(o *option) {
@@ -55,3 +93,43 @@ func SetServiceName(serviceName string) OptionFunc {
 		o.serviceName = serviceName
 	}
 }
+
+// SetRecoverBackoff option func, delay range of consuming again after the channel is closed
+func SetRecoverBackoff(min, max time.Duration) OptionFunc {
+	return func(o *option) {
+		o.recoverMinBackoff = min
+		o.recoverMaxBackoff = max
+	}
+}
+
+// SetRetryPolicy option func, failed message is retried maxRetry times through delayed retry queues,
+// the delay start from minBackoff and doubled every attempt up to maxBackoff
+func SetRetryPolicy(maxRetry int, minBackoff, maxBackoff time.Duration) OptionFunc {
+	return func(o *option) {
+		o.maxRetry = maxRetry
+		o.retryMinBackoff = minBackoff
+		o.retryMaxBackoff = maxBackoff
+	}
+}
+
+// SetRetryConfirmTimeout option func, maximum duration to wait confirmation of retried message before it is requeued
+func SetRetryConfirmTimeout(timeout time.Duration) OptionFunc {
+	return func(o *option) {
+		o.retryConfirmTimeout = timeout
+	}
+}
+
+// SetDeadLetterExchange option func, exchange of message which retry is exhausted, empty reject the message.
+// Dead-letter exchange in queue arguments of the handler wins, the message is rejected to it instead
+func SetDeadLetterExchange(exchange string) OptionFunc {
+	return func(o *option) {
+		o.deadLetterExchange = exchange
+	}
+}
+
+// SetDryRun option func, validate topology against broker without declaring and consuming
+func SetDryRun(dryRun bool) OptionFunc {
+	return func(o *option) {
+		o.dryRun = dryRun
+	}
+}
@@ -1,27 +1,239 @@
 package rabbitmq
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/streadway/amqp"
+	"github.com/vizucode/gokit/types"
 )
 
-func setupQueueConfig(ch *amqp.Channel, exchangeName, queueName string) (<-chan amqp.Delivery, error) {
-	queue, err := ch.QueueDeclare(queueName, true, false, false, false, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error in declaring the queue %s", err)
+// topology exchange, queue and bindings of a handler resolved from types.BrokerHandler
+type topology struct {
+	exchange     string
+	exchangeType types.ExchangeType
+	queue        string
+	durable      bool
+	exclusive    bool
+	bindingKeys  []string
+	bindingArgs  amqp.Table
+	queueArgs    amqp.Table
+	consumerTag  string
+
+	// ownExchange exchange type is set, the exchange is declared by consumer instead of assumed to exist
+	ownExchange bool
+}
+
+// newTopology validate handler and resolve defaults, binding keys default to topic or queue name
+func newTopology(h types.BrokerHandler) (topology, error) {
+	t := topology{
+		exchange:     h.Exchange,
+		exchangeType: h.ExchangeType,
+		ownExchange:  h.ExchangeType != "",
+		queue:        h.Queue,
+		durable:      h.IsQueueDurable,
+		exclusive:    h.IsQueueExclusive,
+		bindingKeys:  h.BindingKeys,
+		bindingArgs:  amqp.Table(h.BindingArgs),
+		queueArgs:    amqp.Table{},
+		consumerTag:  h.Channel,
+	}
+
+	var errs []error
+	if t.queue == "" {
+		errs = append(errs, errors.New("queue is required"))
+	}
+
+	switch t.exchangeType {
+	case "":
+		t.exchangeType = types.ExchangeDirect
+	case types.ExchangeDirect, types.ExchangeTopic, types.ExchangeFanout, types.ExchangeHeaders:
+	default:
+		errs = append(errs, fmt.Errorf("unknown exchange type %q", t.exchangeType))
+	}
+
+	if len(t.bindingKeys) == 0 {
+		switch {
+		case t.exchangeType == types.ExchangeFanout, t.exchangeType == types.ExchangeHeaders:
+			t.bindingKeys = []string{""}
+		case h.Topic != "":
+			t.bindingKeys = []string{h.Topic}
+		default:
+			t.bindingKeys = []string{t.queue}
+		}
+	}
+
+	if t.exchange == "" && (h.ExchangeType != "" || len(h.BindingKeys) > 0 || len(h.BindingArgs) > 0) {
+		errs = append(errs, errors.New("exchange is required by exchange type and bindings"))
+	}
+	if t.exchangeType == types.ExchangeHeaders && len(t.bindingArgs) == 0 {
+		errs = append(errs, errors.New("headers exchange require binding arguments"))
+	}
+
+	args := h.QueueArgs
+	if args.MessageTTL < 0 || args.MaxLength < 0 {
+		errs = append(errs, errors.New("message ttl and max length must not be negative"))
+	}
+	if args.Quorum && (!t.durable || t.exclusive) {
+		errs = append(errs, errors.New("quorum queue must be durable and not exclusive"))
+	}
+	if args.DeadLetterRoutingKey != "" && args.DeadLetterExchange == "" {
+		errs = append(errs, errors.New("dead-letter routing key require dead-letter exchange"))
+	}
+
+	for k, v := range args.Extra {
+		t.queueArgs[k] = v
+	}
+	if args.MessageTTL > 0 {
+		t.queueArgs["x-message-ttl"] = args.MessageTTL.Milliseconds()
+	}
+	if args.MaxLength > 0 {
+		t.queueArgs["x-max-length"] = int64(args.MaxLength)
+	}
+	if args.Quorum {
+		t.queueArgs["x-queue-type"] = "quorum"
+	}
+	if args.DeadLetterExchange != "" {
+		t.queueArgs["x-dead-letter-exchange"] = args.DeadLetterExchange
+	}
+	if args.DeadLetterRoutingKey != "" {
+		t.queueArgs["x-dead-letter-routing-key"] = args.DeadLetterRoutingKey
+	}
+	if err := t.queueArgs.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if t.consumerTag == "" {
+		t.consumerTag = t.queue
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return t, fmt.Errorf("invalid topology of queue %q: %w", t.queue, err)
+	}
+
+	return t, nil
+}
+
+// declareExchange exchange with type is declared unless it is predeclared amq.* exchange
+func (t topology) declareExchange() bool {
+	return t.ownExchange && t.exchange != "" && !strings.HasPrefix(t.exchange, "amq.")
+}
+
+// ownDeadLetter queue has dead-letter exchange in its arguments, rejected message is routed by the queue
+func (t topology) ownDeadLetter() bool {
+	_, ok := t.queueArgs["x-dead-letter-exchange"]
+	return ok
+}
+
+func (t topology) exchangeDeclare(ch *amqp.Channel) error {
+	return ch.ExchangeDeclare(t.exchange, t.exchangeType.String(), true, false, false, false, nil)
+}
+
+func (t topology) queueDeclare(ch *amqp.Channel) error {
+	_, err := ch.QueueDeclare(t.queue, t.durable, false, t.exclusive, false, t.queueArgs)
+	return err
+}
+
+// declare exchange, queue and bindings, queue of default exchange is not bound
+func (t topology) declare(ch *amqp.Channel) error {
+	if t.declareExchange() {
+		if err := t.exchangeDeclare(ch); err != nil {
+			return fmt.Errorf("error in declaring the exchange %s", err)
+		}
+	}
+
+	if err := t.queueDeclare(ch); err != nil {
+		return fmt.Errorf("error in declaring the queue %s", err)
+	}
+
+	if t.exchange == "" {
+		return nil
+	}
+
+	for _, key := range t.bindingKeys {
+		if err := ch.QueueBind(t.queue, key, t.exchange, false, t.bindingArgs); err != nil {
+			return fmt.Errorf("error binding queue: %s", err)
+		}
 	}
-	if err = ch.QueueBind(queue.Name, queue.Name, exchangeName, false, nil); err != nil {
-		return nil, fmt.Errorf("error binding queue: %s", err)
+
+	return nil
+}
+
+// inspect check topology against broker without changing it. Missing exchange or queue is reported to be
+// declared, existing one is declared again with the same parameters which fail when they differ
+func (t topology) inspect(open func() (*amqp.Channel, error)) (plan []string, err error) {
+	check := func(declare func(ch *amqp.Channel) error) error {
+		ch, err := open()
+		if err != nil {
+			return err
+		}
+		defer ch.Close()
+
+		return declare(ch)
+	}
+
+	if t.exchange != "" {
+		err = check(func(ch *amqp.Channel) error {
+			return ch.ExchangeDeclarePassive(t.exchange, t.exchangeType.String(), true, false, false, false, nil)
+		})
+		switch {
+		case isNotFound(err) && !t.declareExchange():
+			return plan, fmt.Errorf("exchange %q does not exist, set exchange type to declare it", t.exchange)
+		case isNotFound(err):
+			plan = append(plan, fmt.Sprintf("exchange %q (%s) will be declared", t.exchange, t.exchangeType))
+		case err != nil:
+			return plan, err
+		case !t.declareExchange():
+			plan = append(plan, fmt.Sprintf("exchange %q exists", t.exchange))
+		default:
+			if err = check(t.exchangeDeclare); err != nil {
+				return plan, fmt.Errorf("exchange %q differ from broker: %w", t.exchange, err)
+			}
+			plan = append(plan, fmt.Sprintf("exchange %q (%s) is up to date", t.exchange, t.exchangeType))
+		}
+	}
+
+	err = check(func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclarePassive(t.queue, t.durable, false, t.exclusive, false, t.queueArgs)
+		return err
+	})
+	switch {
+	case isNotFound(err):
+		plan = append(plan, fmt.Sprintf("queue %q will be declared", t.queue))
+	case err != nil:
+		return plan, err
+	default:
+		if err = check(t.queueDeclare); err != nil {
+			return plan, fmt.Errorf("queue %q differ from broker: %w", t.queue, err)
+		}
+		plan = append(plan, fmt.Sprintf("queue %q is up to date", t.queue))
+	}
+
+	if t.exchange != "" {
+		plan = append(plan, fmt.Sprintf("queue %q bound to %q with keys %q", t.queue, t.exchange, t.bindingKeys))
+	}
+
+	return plan, nil
+}
+
+func isNotFound(err error) bool {
+	var amqpErr *amqp.Error
+	return errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound
+}
+
+func setupQueueConfig(ch *amqp.Channel, t topology) (<-chan amqp.Delivery, error) {
+	if err := t.declare(ch); err != nil {
+		return nil, err
 	}
 
 	return ch.Consume(
-		queue.Name,
-		queue.Name, // consumer or channel consumer
-		false,      // auto ack
-		false,      // exclusive
-		false,      // no local
-		false,      // no waiting
-		nil,        // arguments
+		t.queue,
+		t.consumerTag, // consumer or channel consumer
+		false,         // auto ack
+		t.exclusive,   // exclusive
+		false,         // no local
+		false,         // no waiting
+		nil,           // arguments
 	)
 }
@@ -0,0 +1,77 @@
+package rabbitmq
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/vizucode/gokit/types"
+)
+
+func TestNewTopology(t *testing.T) {
+	top, err := newTopology(types.BrokerHandler{
+		Exchange:       "order",
+		ExchangeType:   types.ExchangeTopic,
+		Queue:          "order-created",
+		IsQueueDurable: true,
+		Channel:        "order-service",
+		BindingKeys:    []string{"order.*.created", "order.#.paid"},
+		QueueArgs: types.QueueArguments{
+			MessageTTL:         time.Minute,
+			MaxLength:          100,
+			Quorum:             true,
+			DeadLetterExchange: "order-dlx",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !top.declareExchange() || top.consumerTag != "order-service" {
+		t.Errorf("unexpected topology %+v", top)
+	}
+	if !reflect.DeepEqual(top.bindingKeys, []string{"order.*.created", "order.#.paid"}) {
+		t.Errorf("unexpected binding keys %q", top.bindingKeys)
+	}
+
+	want := amqp.Table{
+		"x-message-ttl":          int64(60000),
+		"x-max-length":           int64(100),
+		"x-queue-type":           "quorum",
+		"x-dead-letter-exchange": "order-dlx",
+	}
+	if !reflect.DeepEqual(top.queueArgs, want) {
+		t.Errorf("got queue args %v, want %v", top.queueArgs, want)
+	}
+}
+
+func TestNewTopologyDefault(t *testing.T) {
+	top, err := newTopology(types.BrokerHandler{Exchange: "order", Queue: "order-created", Topic: "order.created", IsQueueDurable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if top.declareExchange() || top.consumerTag != "order-created" || !reflect.DeepEqual(top.bindingKeys, []string{"order.created"}) {
+		t.Errorf("unexpected topology %+v", top)
+	}
+}
+
+func TestNewTopologyInvalid(t *testing.T) {
+	cases := map[string]types.BrokerHandler{
+		"missing queue":     {Exchange: "order"},
+		"unknown type":      {Exchange: "order", Queue: "q", ExchangeType: "direct-x"},
+		"default exchange":  {Queue: "q", BindingKeys: []string{"a"}},
+		"headers args":      {Exchange: "order", Queue: "q", ExchangeType: types.ExchangeHeaders},
+		"exclusive quorum":  {Exchange: "order", Queue: "q", IsQueueDurable: true, IsQueueExclusive: true, QueueArgs: types.QueueArguments{Quorum: true}},
+		"negative ttl":      {Exchange: "order", Queue: "q", QueueArgs: types.QueueArguments{MessageTTL: -time.Second}},
+		"dead-letter key":   {Exchange: "order", Queue: "q", QueueArgs: types.QueueArguments{DeadLetterRoutingKey: "k"}},
+		"invalid extra arg": {Exchange: "order", Queue: "q", QueueArgs: types.QueueArguments{Extra: map[string]interface{}{"x-foo": struct{}{}}}},
+	}
+
+	for name, h := range cases {
+		if _, err := newTopology(h); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,12 +10,12 @@ import (
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/vizucode/gokit/factory"
 	"github.com/vizucode/gokit/logger"
 	"github.com/vizucode/gokit/tracer"
 	"github.com/vizucode/gokit/types"
 	"github.com/vizucode/gokit/utils/convert"
+	"github.com/vizucode/gokit/utils/recovery"
 	"github.com/vizucode/gokit/utils/timezone"
 
 	"github.com/streadway/amqp"
@@ -25,13 +26,17 @@ type rabbitMqWorker struct {
 	cancelFunc func()
 	opt        option
 	tz         *time.Location
-	ch         *amqp.Channel
-	shutdown   chan struct{}
-	isShutdown bool
-	semaphore  []chan struct{}
-	wg         sync.WaitGroup
-	channels   []reflect.SelectCase
-	handlers   map[string]types.BrokerHandler
+	conn       connection
+	// stop cancelled by shutdown, stop consuming and recovering
+	stop     context.Context
+	stopFunc func()
+	// mu guard stopped, so running handler is not added after shutdown start waiting
+	mu        sync.Mutex
+	stopped   bool
+	wg        sync.WaitGroup
+	consumers []*queueConsumer
+	// retrier publish retried message with confirmation
+	retrier *confirmPublisher
 }
 
 // New create new rabbitmq consumer
@@ -53,46 +58,98 @@ func New(service factory.ServiceFactory, opts ...OptionFunc) factory.Application
 	}
 
 	worker.ctx, worker.cancelFunc = context.WithCancel(context.Background())
-	worker.ch = service.GetBroker(types.RabbitMQ).GetConfiguration().(*amqp.Channel)
-	worker.shutdown = make(chan struct{}, 1)
-	worker.handlers = make(map[string]types.BrokerHandler)
+	worker.stop, worker.stopFunc = context.WithCancel(context.Background())
+
+	switch conf := service.GetBroker(types.RabbitMQ).GetConfiguration().(type) {
+	case connection:
+		worker.conn = conf
+	case *amqp.Channel:
+		worker.conn = staticChannel{ch: conf}
+	default:
+		log.Fatalf("unsupported rabbitmq configuration %T", conf)
+	}
+
+	worker.retrier = newConfirmPublisher(func() (confirmChannel, error) {
+		ch, err := worker.conn.Channel(worker.stop)
+		if err != nil {
+			return nil, err
+		}
+		return ch, nil
+	}, worker.opt.retryConfirmTimeout)
 
 	if h := service.BrokerHandler(types.RabbitMQ); h != nil {
 		var hg types.BrokerHandlerGroup
 		h.Register(&hg)
 
-		for _, handler := range hg.Handlers {
-			worker.opt.exchangeName, worker.opt.queue, worker.opt.isAutoAck = handler.Exchange, handler.Queue, handler.IsAutoAck
+		if worker.opt.dryRun {
+			if err := worker.dryRun(hg.Handlers); err != nil {
+				panic(err)
+			}
+			logger.PurpleBold("⇨ RabbitMQ topology is valid, consumer is not started on dry run")
+			return worker
+		}
 
-			queueChan, err := setupQueueConfig(worker.ch, worker.opt.exchangeName, worker.opt.queue)
+		for _, handler := range hg.Handlers {
+			q, err := worker.newQueueConsumer(handler)
 			if err != nil {
 				panic(err)
 			}
-			logger.Purple(fmt.Sprintf(`[RABBITMQ-CONSUMER] (exchange): %-15s (queue): %-15s`, `"`+worker.opt.exchangeName+`"`, `"`+worker.opt.queue+`"`))
-
-			worker.channels = append(
-				worker.channels, reflect.SelectCase{
-					Dir: reflect.SelectRecv, Chan: reflect.ValueOf(queueChan),
-				},
-			)
-			worker.handlers[worker.opt.queue] = handler
-			worker.semaphore = append(worker.semaphore, make(chan struct{}, 1))
+			if err = q.consume(); err != nil {
+				panic(err)
+			}
+
+			logger.Purple(fmt.Sprintf(`[RABBITMQ-CONSUMER] (exchange): %-15s (queue): %-15s (prefetch): %d (concurrency): %d`,
+				`"`+handler.Exchange+`"`, `"`+handler.Queue+`"`, q.prefetch, cap(q.semaphore)))
+			worker.consumers = append(worker.consumers, q)
 		}
 	}
-	logger.PurpleBold(fmt.Sprintf("⇨ RabbitMQ consumer running with %d queue", len(worker.channels)))
+
+	logger.PurpleBold(fmt.Sprintf("⇨ RabbitMQ consumer running with %d queue", len(worker.consumers)))
 	return worker
 }
 
+// dryRun validate topology of every handler and check it against broker without declaring anything
+func (r *rabbitMqWorker) dryRun(handlers []types.BrokerHandler) error {
+	open := func() (*amqp.Channel, error) {
+		return r.conn.Channel(r.stop)
+	}
+
+	var errs []error
+	for _, handler := range handlers {
+		t, err := newTopology(handler)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// retry and dead-letter queues are declared by consumer as well
+		for _, declared := range append([]topology{t}, r.retryTopology(t)...) {
+			plan, err := declared.inspect(open)
+			for _, step := range plan {
+				logger.Purple(fmt.Sprintf("[RABBITMQ-TOPOLOGY] %s", step))
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("topology of queue %q: %w", declared.queue, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func (r *rabbitMqWorker) Name() string {
 	return types.RabbitMQ.String()
 }
 
 func (r *rabbitMqWorker) Shutdown(_ context.Context) {
-	r.shutdown <- struct{}{}
-	r.isShutdown = true
+	r.mu.Lock()
+	r.stopped = true
+	r.stopFunc()
+	r.mu.Unlock()
+
 	var runningJob int
-	for _, semp := range r.semaphore {
-		runningJob += len(semp)
+	for _, q := range r.consumers {
+		runningJob += len(q.semaphore)
 	}
 
 	if runningJob != 0 {
@@ -101,41 +158,42 @@ func (r *rabbitMqWorker) Shutdown(_ context.Context) {
 
 	r.wg.Wait()
 	defer logger.RedBold("Stopping RabbitMQ Broker")
-	_ = r.ch.Close()
+	for _, q := range r.consumers {
+		q.close()
+	}
+	r.retrier.close()
 	r.cancelFunc()
 }
 
-func (r *rabbitMqWorker) Serve() {
-	for {
-		select {
-		case <-r.shutdown:
-			return
-		default:
-		}
+// track add running handler, false when shutdown already started
+func (r *rabbitMqWorker) track() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-		chosen, value, ok := reflect.Select(r.channels)
-		if !ok {
-			continue
-		}
+	if r.stopped {
+		return false
+	}
 
-		// execute handler
-		if msg, ok := value.Interface().(amqp.Delivery); ok {
-			r.semaphore[chosen] <- struct{}{}
-			if r.isShutdown {
-				return
-			}
+	r.wg.Add(1)
+	return true
+}
 
-			r.wg.Add(1)
-			go func(message amqp.Delivery, index int) {
-				r.processMessage(message)
-				r.wg.Done()
-				<-r.semaphore[index]
-			}(msg, chosen)
-		}
+// Serve consume every queue on its own goroutine until shutdown
+func (r *rabbitMqWorker) Serve() {
+	var wg sync.WaitGroup
+	for _, q := range r.consumers {
+		wg.Add(1)
+		go func(q *queueConsumer) {
+			defer wg.Done()
+			q.serve()
+		}(q)
 	}
+	wg.Wait()
 }
 
-func (r *rabbitMqWorker) processMessage(message amqp.Delivery) {
+// processMessage ack message handled successfully, failed message is retried by retry policy.
+// Message of auto ack handler is acked regardless of the result
+func (r *rabbitMqWorker) processMessage(message amqp.Delivery, selectedHandler types.BrokerHandler, t topology) {
 	start := time.Now().In(r.tz)
 
 	if r.ctx.Err() != nil {
@@ -143,8 +201,8 @@ func (r *rabbitMqWorker) processMessage(message amqp.Delivery) {
 		return
 	}
 
-	ctx := r.ctx
-	selectedHandler := r.handlers[message.RoutingKey]
+	// continue trace and request id of publisher
+	ctx, requestId := extractInbound(r.ctx, message.Headers)
 
 	header := map[string]string{}
 	for key, val := range message.Headers {
@@ -158,10 +216,10 @@ func (r *rabbitMqWorker) processMessage(message amqp.Delivery) {
 	// init logger data
 	ol := &logger.DataLogger{
 		TimeStart:     start,
-		RequestId:     uuid.NewString(),
+		RequestId:     requestId,
 		Type:          logger.ServiceType(types.RabbitMQ.String()),
 		Service:       r.opt.serviceName,
-		Endpoint:      fmt.Sprintf("queue: %s", r.opt.queue),
+		Endpoint:      fmt.Sprintf("queue: %s", selectedHandler.Queue),
 		RequestBody:   string(message.Body),
 		RequestMethod: "CONSUME",
 		RequestHeader: fmt.Sprintf("Exchange: %s | Routing Key: %s | Header: %v", message.Exchange, message.RoutingKey, header),
@@ -169,16 +227,13 @@ func (r *rabbitMqWorker) processMessage(message amqp.Delivery) {
 
 	defer func() {
 		if re := recover(); re != nil {
-			err = fmt.Errorf("%s", re)
+			p := recovery.New(ol.Type.String(), ol.Service, ol.Endpoint, re)
+			p.Record(ctx, trace)
+			err = p
 		}
 
 		sc := http.StatusOK
 
-		ack := false
-		if r.opt.isAutoAck {
-			ack = true
-		}
-
 		if err != nil {
 			trace.SetError(err)
 
@@ -189,11 +244,15 @@ func (r *rabbitMqWorker) processMessage(message amqp.Delivery) {
 			ol.Response = "success"
 		}
 
-		if ack {
-			_ = message.Ack(true)
-		} else {
-			_ = message.Reject(true)
-			_ = message.Nack(true, true)
+		switch {
+		case err == nil || selectedHandler.IsAutoAck:
+			_ = message.Ack(false)
+		default:
+			attempt, retryErr := r.retry(message, t, err)
+			trace.SetTag("retry_attempt", attempt)
+			if retryErr != nil {
+				log.Printf("rabbitmq_consumer > retry err: %s", retryErr)
+			}
 		}
 
 		trace.SetTag("trace_id", tracer.GetTraceID(ctx))
@@ -209,7 +268,9 @@ func (r *rabbitMqWorker) processMessage(message amqp.Delivery) {
 	var lock = new(logger.Locker)
 	// set to context with logger.LogKey as a context key
 	ctx = context.WithValue(ctx, logger.LogKey, lock)
+	lock.Set(logger.RequestId, requestId)
 
+	trace.SetTag("request_id", requestId)
 	trace.SetTag("exchange", message.Exchange)
 	trace.SetTag("routing_key", message.RoutingKey)
 	trace.SetTag("body", message.Body)
@@ -217,11 +278,14 @@ func (r *rabbitMqWorker) processMessage(message amqp.Delivery) {
 
 	log.Printf("\x1b[35;3mRabbitMQ Consumer: message consumed, topic = %s\x1b[0m", message.RoutingKey)
 
+	// retried message is delivered through default exchange, handler see the route of the first delivery
+	exchange, routingKey := originalRoute(message)
+
 	var ec = types.EventContext{}
 	ec.SetContext(ctx)
 	ec.SetWorkerType(types.RabbitMQ.String())
-	ec.SetHandlerRoute(message.RoutingKey)
-	ec.SetKey(message.Exchange)
+	ec.SetHandlerRoute(routingKey)
+	ec.SetKey(exchange)
 	ec.SetHeader(header)
 	_, _ = ec.Write(message.Body)
 
@@ -0,0 +1,181 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/vizucode/gokit/types"
+	"github.com/vizucode/gokit/utils/backoff"
+)
+
+const (
+	// HeaderRetryAttempt number of retry of the message, zero or absent on the first delivery
+	HeaderRetryAttempt = "x-retry-attempt"
+	// HeaderRetryError error of the last failed attempt
+	HeaderRetryError = "x-retry-error"
+	// HeaderOriginalExchange exchange of the first delivery, retried message is delivered through default exchange
+	HeaderOriginalExchange = "x-original-exchange"
+	// HeaderOriginalRoutingKey routing key of the first delivery, retried message is routed by queue name
+	HeaderOriginalRoutingKey = "x-original-routing-key"
+
+	// maxErrorHeader maximum length of error written into header
+	maxErrorHeader = 512
+)
+
+// retryDelay delay before the given retry attempt starting from one
+func (r *rabbitMqWorker) retryDelay(attempt int) time.Duration {
+	return backoff.Exponential(attempt-1, r.opt.retryMinBackoff, r.opt.retryMaxBackoff)
+}
+
+// retryQueue name of delayed retry queue of queue, e.g. order.retry.2000ms
+func retryQueue(queue string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", queue, delay.Milliseconds())
+}
+
+// deadLetterQueue name of queue keeping messages of queue routed to dead-letter exchange
+func deadLetterQueue(queue string) string {
+	return queue + ".dead"
+}
+
+// retryTopology delayed retry queues of every distinct delay, expired message is dead-lettered back to the
+// queue through default exchange. Dead-letter queue is bound to dead-letter exchange by queue name, it is
+// not declared when the queue has its own dead-letter exchange
+func (r *rabbitMqWorker) retryTopology(t topology) []topology {
+	var retries []topology
+
+	declared := map[time.Duration]bool{}
+	for attempt := 1; attempt <= r.opt.maxRetry; attempt++ {
+		delay := r.retryDelay(attempt)
+		if declared[delay] {
+			continue
+		}
+		declared[delay] = true
+
+		retries = append(retries, topology{
+			queue:   retryQueue(t.queue, delay),
+			durable: true,
+			queueArgs: amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": t.queue,
+			},
+		})
+	}
+
+	if r.opt.deadLetterExchange == "" || t.ownDeadLetter() {
+		return retries
+	}
+
+	return append(retries, topology{
+		exchange:     r.opt.deadLetterExchange,
+		exchangeType: types.ExchangeDirect,
+		ownExchange:  true,
+		queue:        deadLetterQueue(t.queue),
+		durable:      true,
+		bindingKeys:  []string{t.queue},
+	})
+}
+
+// setupRetry declare retry and dead-letter topology of t
+func (r *rabbitMqWorker) setupRetry(ch *amqp.Channel, t topology) error {
+	for _, retry := range r.retryTopology(t) {
+		if err := retry.declare(ch); err != nil {
+			return fmt.Errorf("retry topology of queue %s: %w", t.queue, err)
+		}
+	}
+
+	return nil
+}
+
+// retry publish failed message into delayed retry queue, or into dead-letter exchange when retry is exhausted.
+// The message is rejected instead when the queue has its own dead-letter exchange, so dead-letter exchange of
+// the handler wins over the worker's, or when there is no dead-letter exchange at all. The message is acked
+// only after the broker route it to a queue and confirm the publish, otherwise it is requeued
+func (r *rabbitMqWorker) retry(message amqp.Delivery, t topology, cause error) (attempt int, err error) {
+	attempt = retryAttempt(message.Headers) + 1
+
+	exchange, key := "", ""
+	switch {
+	case attempt <= r.opt.maxRetry:
+		key = retryQueue(t.queue, r.retryDelay(attempt))
+	case t.ownDeadLetter(), r.opt.deadLetterExchange == "":
+		return attempt, message.Nack(false, false)
+	default:
+		exchange, key = r.opt.deadLetterExchange, t.queue
+	}
+
+	headers := amqp.Table{}
+	for k, v := range message.Headers {
+		headers[k] = v
+	}
+	headers[HeaderRetryAttempt] = int32(attempt)
+	if _, ok := headers[HeaderOriginalExchange]; !ok {
+		headers[HeaderOriginalExchange] = message.Exchange
+		headers[HeaderOriginalRoutingKey] = message.RoutingKey
+	}
+
+	errMessage := cause.Error()
+	if len(errMessage) > maxErrorHeader {
+		errMessage = errMessage[:maxErrorHeader]
+	}
+	headers[HeaderRetryError] = errMessage
+
+	if err = r.retrier.publish(exchange, key, amqp.Publishing{
+		Headers:         headers,
+		ContentType:     message.ContentType,
+		ContentEncoding: message.ContentEncoding,
+		DeliveryMode:    amqp.Persistent,
+		Priority:        message.Priority,
+		CorrelationId:   message.CorrelationId,
+		ReplyTo:         message.ReplyTo,
+		MessageId:       message.MessageId,
+		Timestamp:       message.Timestamp,
+		Type:            message.Type,
+		AppId:           message.AppId,
+		Body:            message.Body,
+	}); err != nil {
+		// keep the message on the queue instead of losing it, it may be delivered twice when confirmation is lost
+		_ = message.Nack(false, true)
+		return attempt, fmt.Errorf("rabbitmq: publish retry: %w", err)
+	}
+
+	return attempt, message.Ack(false)
+}
+
+// originalRoute exchange and routing key of the first delivery of message
+func originalRoute(message amqp.Delivery) (exchange, key string) {
+	exchange, key = message.Exchange, message.RoutingKey
+	if v, ok := message.Headers[HeaderOriginalExchange].(string); ok {
+		exchange = v
+	}
+	if v, ok := message.Headers[HeaderOriginalRoutingKey].(string); ok {
+		key = v
+	}
+
+	return exchange, key
+}
+
+// retryAttempt read retry attempt header, integer type depends on publisher
+func retryAttempt(headers amqp.Table) int {
+	switch v := headers[HeaderRetryAttempt].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	default:
+		return 0
+	}
+}
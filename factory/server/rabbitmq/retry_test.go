@@ -0,0 +1,249 @@
+package rabbitmq
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/vizucode/gokit/types"
+)
+
+func TestRetryQueue(t *testing.T) {
+	r := &rabbitMqWorker{opt: option{maxRetry: 4, retryMinBackoff: time.Second, retryMaxBackoff: 5 * time.Second}}
+
+	want := []string{"order.retry.1000ms", "order.retry.2000ms", "order.retry.4000ms", "order.retry.5000ms"}
+	for i, name := range want {
+		if got := retryQueue("order", r.retryDelay(i+1)); got != name {
+			t.Errorf("attempt %d: got %s, want %s", i+1, got, name)
+		}
+	}
+}
+
+func TestRetryAttempt(t *testing.T) {
+	cases := []struct {
+		headers amqp.Table
+		want    int
+	}{
+		{nil, 0},
+		{amqp.Table{HeaderRetryAttempt: int32(2)}, 2},
+		{amqp.Table{HeaderRetryAttempt: int64(3)}, 3},
+		{amqp.Table{HeaderRetryAttempt: "1"}, 0},
+	}
+
+	for _, c := range cases {
+		if got := retryAttempt(c.headers); got != c.want {
+			t.Errorf("headers %v: got %d, want %d", c.headers, got, c.want)
+		}
+	}
+}
+
+// fakeChannel answer every publish with confirmation unless silent, published messages are kept.
+// Unroutable message is returned before its confirmation as broker do
+type fakeChannel struct {
+	ack        bool
+	silent     bool
+	unroutable bool
+	confirms   chan amqp.Confirmation
+	returns    chan amqp.Return
+
+	mu        sync.Mutex
+	published []amqp.Publishing
+	keys      []string
+}
+
+func (f *fakeChannel) Confirm(bool) error { return nil }
+
+func (f *fakeChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	f.confirms = confirm
+	return confirm
+}
+
+func (f *fakeChannel) NotifyReturn(ret chan amqp.Return) chan amqp.Return {
+	f.returns = ret
+	return ret
+}
+
+func (f *fakeChannel) Publish(_, key string, mandatory, _ bool, msg amqp.Publishing) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.published = append(f.published, msg)
+	f.keys = append(f.keys, key)
+	tag := uint64(len(f.published))
+
+	go func() {
+		if f.unroutable && mandatory {
+			f.returns <- amqp.Return{ReplyCode: amqp.NoRoute, RoutingKey: key, Headers: msg.Headers}
+		}
+		if !f.silent {
+			f.confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: f.ack}
+		}
+	}()
+
+	return nil
+}
+
+func (f *fakeChannel) Close() error {
+	close(f.confirms)
+	close(f.returns)
+	return nil
+}
+
+// fakeAcknowledger record ack and nack of delivery
+type fakeAcknowledger struct {
+	acked, nacked, requeued bool
+}
+
+func (f *fakeAcknowledger) Ack(uint64, bool) error {
+	f.acked = true
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(_ uint64, _, requeue bool) error {
+	f.nacked, f.requeued = true, requeue
+	return nil
+}
+
+func (f *fakeAcknowledger) Reject(_ uint64, requeue bool) error {
+	return f.Nack(0, false, requeue)
+}
+
+func newRetryWorker(ch *fakeChannel) *rabbitMqWorker {
+	r := &rabbitMqWorker{opt: option{maxRetry: 3, retryMinBackoff: time.Second, retryMaxBackoff: time.Minute}}
+	r.retrier = newConfirmPublisher(func() (confirmChannel, error) { return ch, nil }, 100*time.Millisecond)
+
+	return r
+}
+
+func TestRetry(t *testing.T) {
+	tests := map[string]struct {
+		channel *fakeChannel
+		acked   bool
+	}{
+		"confirmed": {channel: &fakeChannel{ack: true}, acked: true},
+		"nacked":    {channel: &fakeChannel{}},
+		"timeout":   {channel: &fakeChannel{silent: true}},
+		// retry queue of another retry policy is not declared, broker return the message and ack it
+		"unroutable": {channel: &fakeChannel{ack: true, unroutable: true}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := newRetryWorker(tt.channel)
+
+			acknowledger := &fakeAcknowledger{}
+			attempt, err := r.retry(amqp.Delivery{Acknowledger: acknowledger}, topology{queue: "order"}, errors.New("failed"))
+			if attempt != 1 || tt.channel.keys[0] != "order.retry.1000ms" {
+				t.Fatalf("attempt %d published to %s", attempt, tt.channel.keys[0])
+			}
+
+			// message is acked only after the retry is confirmed, otherwise it is requeued
+			if tt.acked != (err == nil) || acknowledger.acked != tt.acked || acknowledger.requeued == tt.acked {
+				t.Fatalf("err %v, acked %v, requeued %v", err, acknowledger.acked, acknowledger.requeued)
+			}
+		})
+	}
+}
+
+func TestRetryOriginalRoute(t *testing.T) {
+	ch := &fakeChannel{ack: true}
+	r := newRetryWorker(ch)
+
+	message := amqp.Delivery{Acknowledger: &fakeAcknowledger{}, Exchange: "order", RoutingKey: "order.created"}
+	for i := 0; i < 2; i++ {
+		if _, err := r.retry(message, topology{queue: "order"}, errors.New("failed")); err != nil {
+			t.Fatal(err)
+		}
+
+		// retried message is delivered again through default exchange with queue name as routing key
+		message = amqp.Delivery{Acknowledger: message.Acknowledger, Headers: ch.published[i].Headers, RoutingKey: "order"}
+		if exchange, key := originalRoute(message); exchange != "order" || key != "order.created" {
+			t.Fatalf("retry %d: original route %q %q", i+1, exchange, key)
+		}
+	}
+}
+
+func TestConfirmPublisherClosed(t *testing.T) {
+	ch := &fakeChannel{silent: true}
+	p := newConfirmPublisher(func() (confirmChannel, error) { return ch, nil }, time.Second)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		p.close()
+	}()
+
+	if err := p.publish("", "order", amqp.Publishing{}); !errors.Is(err, amqp.ErrClosed) {
+		t.Fatalf("got %v, want closed", err)
+	}
+}
+
+func TestRetryTopology(t *testing.T) {
+	r := &rabbitMqWorker{opt: option{maxRetry: 3, retryMinBackoff: time.Second, retryMaxBackoff: 2 * time.Second, deadLetterExchange: "dead-letter"}}
+
+	own, err := newTopology(types.BrokerHandler{Queue: "order", QueueArgs: types.QueueArguments{DeadLetterExchange: "order-dlx"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		topology topology
+		want     []string
+	}{
+		"worker dead-letter": {topology: topology{queue: "order"}, want: []string{"order.retry.1000ms", "order.retry.2000ms", "order.dead"}},
+		"own dead-letter":    {topology: own, want: []string{"order.retry.1000ms", "order.retry.2000ms"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var queues []string
+			for _, retry := range r.retryTopology(tt.topology) {
+				queues = append(queues, retry.queue)
+			}
+			if !reflect.DeepEqual(queues, tt.want) {
+				t.Fatalf("got %q, want %q", queues, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	own, err := newTopology(types.BrokerHandler{Queue: "order", QueueArgs: types.QueueArguments{DeadLetterExchange: "order-dlx"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		topology           topology
+		deadLetterExchange string
+		rejected           bool
+	}{
+		"worker dead-letter": {topology: topology{queue: "order"}, deadLetterExchange: "dead-letter"},
+		"own dead-letter":    {topology: own, deadLetterExchange: "dead-letter", rejected: true},
+		"no dead-letter":     {topology: topology{queue: "order"}, rejected: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ch := &fakeChannel{ack: true}
+			r := newRetryWorker(ch)
+			r.opt.deadLetterExchange = tt.deadLetterExchange
+
+			acknowledger := &fakeAcknowledger{}
+			message := amqp.Delivery{Acknowledger: acknowledger, Headers: amqp.Table{HeaderRetryAttempt: int32(3)}}
+			if _, err := r.retry(message, tt.topology, errors.New("failed")); err != nil {
+				t.Fatal(err)
+			}
+
+			// rejected message is routed by dead-letter exchange of the queue, never requeued
+			if tt.rejected != (acknowledger.nacked && !acknowledger.requeued) || tt.rejected != (len(ch.keys) == 0) {
+				t.Fatalf("nacked %v, requeued %v, published %q", acknowledger.nacked, acknowledger.requeued, ch.keys)
+			}
+			if !tt.rejected && (ch.keys[0] != "order" || !acknowledger.acked) {
+				t.Fatalf("published to %q, acked %v", ch.keys, acknowledger.acked)
+			}
+		})
+	}
+}
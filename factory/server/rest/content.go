@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/utils/errorkit"
+	"github.com/vizucode/gokit/utils/protolog"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MIMEProtobuf content type of binary protobuf body
+	MIMEProtobuf = "application/x-protobuf"
+	// MIMEProtobufAlt alternative content type of binary protobuf body
+	MIMEProtobufAlt = "application/protobuf"
+
+	// locals key of decoded request and encoded response, used by http logging to log protobuf as json
+	localRequestMessage  = "rest.request.message"
+	localResponseMessage = "rest.response.message"
+)
+
+var (
+	protoMarshaler   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	protoUnmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+	// logRedactor redact fields with debug_redact option on logging
+	logRedactor = protolog.New()
+)
+
+// Bind decode request body into out based on Content-Type, protobuf message is decoded with
+// protojson for application/json and binary protobuf for application/x-protobuf
+func Bind(c *fiber.Ctx, out interface{}) error {
+	var err error
+
+	msg, isProto := out.(proto.Message)
+	switch {
+	case isProtobuf(c.Get(fiber.HeaderContentType)):
+		if !isProto {
+			return errorkit.Error(fmt.Errorf("protobuf body requires proto.Message, got %T", out), errorkit.UnsupportedMedia, http.StatusUnsupportedMediaType)
+		}
+
+		err = proto.Unmarshal(c.Body(), msg)
+	case isProto && isJSON(c.Get(fiber.HeaderContentType)):
+		err = protoUnmarshaler.Unmarshal(c.Body(), msg)
+	default:
+		err = c.BodyParser(out)
+		if errors.Is(err, fiber.ErrUnprocessableEntity) {
+			return errorkit.Error(err, errorkit.UnsupportedMedia, http.StatusUnsupportedMediaType)
+		}
+	}
+
+	if err != nil {
+		return errorkit.Error(err, errorkit.BadRequest, http.StatusBadRequest)
+	}
+
+	if isProto {
+		c.Locals(localRequestMessage, msg)
+	}
+
+	return nil
+}
+
+// Respond encode out based on Accept header, protobuf message is encoded with protojson
+// for application/json and binary protobuf for application/x-protobuf
+func Respond(c *fiber.Ctx, statusCode int, out interface{}) error {
+	c.Vary(fiber.HeaderAccept)
+
+	msg, isProto := out.(proto.Message)
+	if !isProto {
+		if c.Accepts(fiber.MIMEApplicationJSON) == "" {
+			return errorkit.Error(fmt.Errorf("accept %q is not supported", c.Get(fiber.HeaderAccept)), errorkit.NotAcceptable, http.StatusNotAcceptable)
+		}
+
+		return c.Status(statusCode).JSON(out)
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+
+	accept := c.Accepts(fiber.MIMEApplicationJSON, MIMEProtobuf, MIMEProtobufAlt)
+	switch accept {
+	case fiber.MIMEApplicationJSON:
+		body, err = protoMarshaler.Marshal(msg)
+	case MIMEProtobuf, MIMEProtobufAlt:
+		body, err = proto.Marshal(msg)
+	default:
+		return errorkit.Error(fmt.Errorf("accept %q is not supported", c.Get(fiber.HeaderAccept)), errorkit.NotAcceptable, http.StatusNotAcceptable)
+	}
+
+	if err != nil {
+		return errorkit.Error(err, errorkit.InternalServer, http.StatusInternalServerError)
+	}
+
+	c.Locals(localResponseMessage, msg)
+	c.Set(fiber.HeaderContentType, accept)
+	return c.Status(statusCode).Send(body)
+}
+
+// loggedMessage render protobuf body as json for logging, binary body is kept as base64 when message unknown
+func loggedMessage(c *fiber.Ctx, key, contentType string, body []byte) (string, bool) {
+	if msg, ok := c.Locals(key).(proto.Message); ok {
+		buf, err := logRedactor.Marshal(msg)
+		if err == nil {
+			return string(buf), true
+		}
+	}
+
+	if isProtobuf(contentType) {
+		return base64.StdEncoding.EncodeToString(body), true
+	}
+
+	return "", false
+}
+
+func isProtobuf(contentType string) bool {
+	contentType = mediaType(contentType)
+	return contentType == MIMEProtobuf || contentType == MIMEProtobufAlt
+}
+
+func isJSON(contentType string) bool {
+	contentType = mediaType(contentType)
+	return contentType == "" || contentType == fiber.MIMEApplicationJSON
+}
+
+func mediaType(contentType string) string {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
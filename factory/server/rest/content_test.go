@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/utils/errorkit"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// negotiate run fn on request with body and headers, error is written as its status code
+func negotiate(t *testing.T, body []byte, headers map[string]string, fn fiber.Handler) (int, string, string) {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		var er *errorkit.ErrorResponse
+		if !errors.As(err, &er) {
+			t.Fatalf("error %v is not errorkit error", err)
+		}
+
+		return c.Status(er.StatusCode()).SendString(er.ErrorMessage())
+	}})
+	app.Post("/", fn)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode, resp.Header.Get(fiber.HeaderContentType), string(buf)
+}
+
+func TestBind(t *testing.T) {
+	binary, _ := proto.Marshal(wrapperspb.String("hello"))
+
+	tests := map[string]struct {
+		body        []byte
+		contentType string
+		out         func() interface{}
+		statusCode  int
+	}{
+		"json":                 {[]byte(`{"value":"hello"}`), fiber.MIMEApplicationJSON, func() interface{} { return &struct{ Value string }{} }, http.StatusOK},
+		"protojson":            {[]byte(`"hello"`), fiber.MIMEApplicationJSONCharsetUTF8, func() interface{} { return &wrapperspb.StringValue{} }, http.StatusOK},
+		"protobuf":             {binary, MIMEProtobuf, func() interface{} { return &wrapperspb.StringValue{} }, http.StatusOK},
+		"protobuf alt":         {binary, MIMEProtobufAlt, func() interface{} { return &wrapperspb.StringValue{} }, http.StatusOK},
+		"protobuf into struct": {binary, MIMEProtobuf, func() interface{} { return &struct{ Value string }{} }, http.StatusUnsupportedMediaType},
+		"unsupported":          {[]byte(`hello`), fiber.MIMETextPlain, func() interface{} { return &struct{ Value string }{} }, http.StatusUnsupportedMediaType},
+		"invalid protobuf":     {[]byte{0xff}, MIMEProtobuf, func() interface{} { return &wrapperspb.StringValue{} }, http.StatusBadRequest},
+		"invalid protojson":    {[]byte(`{`), fiber.MIMEApplicationJSON, func() interface{} { return &wrapperspb.StringValue{} }, http.StatusBadRequest},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			statusCode, _, body := negotiate(t, tt.body, map[string]string{fiber.HeaderContentType: tt.contentType}, func(c *fiber.Ctx) error {
+				out := tt.out()
+				if err := Bind(c, out); err != nil {
+					return err
+				}
+
+				if msg, ok := out.(*wrapperspb.StringValue); ok {
+					return c.SendString(msg.GetValue())
+				}
+
+				return c.SendString(out.(*struct{ Value string }).Value)
+			})
+
+			if statusCode != tt.statusCode {
+				t.Fatalf("got %d %s, want %d", statusCode, body, tt.statusCode)
+			}
+
+			if statusCode == http.StatusOK && body != "hello" {
+				t.Errorf("bound %q", body)
+			}
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	binary, _ := proto.Marshal(wrapperspb.String("hello"))
+
+	tests := map[string]struct {
+		accept      string
+		out         interface{}
+		statusCode  int
+		contentType string
+		body        string
+	}{
+		"json":             {fiber.MIMEApplicationJSON, map[string]string{"value": "hello"}, http.StatusCreated, fiber.MIMEApplicationJSON, `{"value":"hello"}`},
+		"protojson":        {"", wrapperspb.String("hello"), http.StatusCreated, fiber.MIMEApplicationJSON, `"hello"`},
+		"protobuf":         {MIMEProtobuf, wrapperspb.String("hello"), http.StatusCreated, MIMEProtobuf, string(binary)},
+		"protobuf alt":     {MIMEProtobufAlt + ";q=0.9, text/html", wrapperspb.String("hello"), http.StatusCreated, MIMEProtobufAlt, string(binary)},
+		"unsupported":      {fiber.MIMETextHTML, wrapperspb.String("hello"), http.StatusNotAcceptable, "", errorkit.NotAcceptable},
+		"unsupported json": {MIMEProtobuf, map[string]string{"value": "hello"}, http.StatusNotAcceptable, "", errorkit.NotAcceptable},
+		"invalid message":  {MIMEProtobuf, wrapperspb.String("\xff"), http.StatusInternalServerError, "", errorkit.InternalServer},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			statusCode, contentType, body := negotiate(t, nil, map[string]string{fiber.HeaderAccept: tt.accept}, func(c *fiber.Ctx) error {
+				return Respond(c, http.StatusCreated, tt.out)
+			})
+
+			if statusCode != tt.statusCode || body != tt.body {
+				t.Fatalf("got %d %q, want %d %q", statusCode, body, tt.statusCode, tt.body)
+			}
+
+			if tt.contentType != "" && !strings.HasPrefix(contentType, tt.contentType) {
+				t.Errorf("content type %s, want %s", contentType, tt.contentType)
+			}
+		})
+	}
+}
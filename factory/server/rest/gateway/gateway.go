@@ -0,0 +1,181 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"github.com/vizucode/gokit/abstract"
+	grpcserver "github.com/vizucode/gokit/factory/server/grpc"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/utils/errorkit"
+	"github.com/vizucode/gokit/utils/request"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Gateway expose grpc services over rest with grpc-gateway, every call is proxied to
+// the grpc server so it is logged and traced by grpc interceptor
+type Gateway struct {
+	opt    option
+	mux    *runtime.ServeMux
+	conn   *grpc.ClientConn
+	server *http.Server
+}
+
+// New create gateway and register handlers of the services
+func New(handler abstract.GatewayHandler, opts ...OptionFunc) (*Gateway, error) {
+	gw := &Gateway{opt: defaultOption()}
+	for _, o := range opts {
+		o(&gw.opt)
+	}
+
+	forward := make(map[string]struct{}, len(gw.opt.forwardHeaders))
+	for _, h := range gw.opt.forwardHeaders {
+		forward[textproto.CanonicalMIMEHeaderKey(h)] = struct{}{}
+	}
+
+	muxOptions := append([]runtime.ServeMuxOption{
+		runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+			if _, ok := forward[textproto.CanonicalMIMEHeaderKey(key)]; ok {
+				return strings.ToLower(key), true
+			}
+			return runtime.DefaultHeaderMatcher(key)
+		}),
+		runtime.WithMetadata(outgoingMetadata),
+		runtime.WithErrorHandler(ErrorHandler),
+		runtime.WithRoutingErrorHandler(routingErrorHandler),
+	}, gw.opt.muxOptions...)
+
+	gw.mux = runtime.NewServeMux(muxOptions...)
+
+	target, dialOptions, err := grpcserver.ResolveTarget(gw.opt.target, gw.opt.dialOptions, gw.opt.server)
+	if err != nil {
+		return nil, fmt.Errorf("grpc gateway: %w", err)
+	}
+
+	// connection is established lazily, grpc server may not be serving yet
+	if gw.conn, err = grpc.NewClient(target, dialOptions...); err != nil {
+		return nil, fmt.Errorf("grpc gateway: dial %s: %w", target, err)
+	}
+
+	if err = handler.RegisterGateway(context.Background(), gw.mux, gw.conn); err != nil {
+		_ = gw.conn.Close()
+		return nil, fmt.Errorf("grpc gateway: register: %w", err)
+	}
+
+	return gw, nil
+}
+
+// Prefix path prefix of gateway mounted in rest server
+func (g *Gateway) Prefix() string {
+	return g.opt.prefix
+}
+
+// OwnListener gateway is served on its own listener instead of mounted in rest server
+func (g *Gateway) OwnListener() bool {
+	return g.opt.listenAddr != ""
+}
+
+// ServeHTTP serve gateway mux, prefix is stripped before routing and path outside prefix is not found
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if g.opt.prefix != "" {
+		path, ok := request.TrimPathPrefix(r.URL.Path, g.opt.prefix)
+		if !ok {
+			routingErrorHandler(r.Context(), g.mux, nil, w, r, http.StatusNotFound)
+			return
+		}
+
+		r.URL.Path = path
+		r.URL.RawPath = ""
+	}
+
+	g.mux.ServeHTTP(w, r)
+}
+
+// Handler mount gateway in fiber, user context (logger, deadline and tracing) is passed to the grpc call
+func (g *Gateway) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		fasthttpadaptor.NewFastHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			g.ServeHTTP(w, r.WithContext(ctx))
+		}))(c.Context())
+
+		return nil
+	}
+}
+
+// Serve gateway on its own listener
+func (g *Gateway) Serve() error {
+	g.server = &http.Server{Addr: g.opt.listenAddr, Handler: g}
+
+	logger.GreenBold(fmt.Sprintf("⇨ GRPC gateway run at %s\n", g.opt.listenAddr))
+	if err := g.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown stop own listener and close connection to grpc server
+func (g *Gateway) Shutdown(ctx context.Context) {
+	if g.server != nil {
+		_ = g.server.Shutdown(ctx)
+	}
+
+	_ = g.conn.Close()
+}
+
+// ErrorHandler render grpc status with errorkit.Envelope as json, message of foreign status is not exposed
+func ErrorHandler(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	var (
+		sc       int
+		envelope errorkit.Envelope
+		he       *runtime.HTTPStatusError
+	)
+
+	if errors.As(err, &he) {
+		// routing error of gateway mux
+		sc = he.HTTPStatus
+		switch sc {
+		case http.StatusNotFound:
+			envelope.Message = errorkit.NotFound
+		case http.StatusMethodNotAllowed:
+			envelope.Message = errorkit.MethodNotAllowed
+		default:
+			envelope.Message = errorkit.BadRequest
+		}
+	} else {
+		sc, envelope = errorkit.ToEnvelope(errorkit.FromStatus(err))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(sc)
+	_ = json.NewEncoder(w).Encode(envelope)
+}
+
+func routingErrorHandler(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, sc int) {
+	ErrorHandler(ctx, mux, m, w, r, &runtime.HTTPStatusError{HTTPStatus: sc, Err: errors.New(http.StatusText(sc))})
+}
+
+// outgoingMetadata propagate request id and trace context of rest request to grpc server
+func outgoingMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	md := metadata.New(carrier)
+	if r.Header.Get("X-Request-Id") == "" && ctx.Value(logger.LogKey) != nil {
+		md.Set("x-request-id", logger.GetRequestId(ctx))
+	}
+
+	return md
+}
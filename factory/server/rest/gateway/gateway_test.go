@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	hpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// healthHandler expose health check at GET /v1/health as generated gateway handler do
+type healthHandler struct{}
+
+func (healthHandler) RegisterGateway(_ context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+	return mux.HandlePath(http.MethodGet, "/v1/health", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/grpc.health.v1.Health/Check")
+		if err != nil {
+			ErrorHandler(ctx, mux, nil, w, r, err)
+			return
+		}
+
+		resp, err := hpb.NewHealthClient(conn).Check(ctx, &hpb.HealthCheckRequest{})
+		if err != nil {
+			ErrorHandler(ctx, mux, nil, w, r, err)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": resp.GetStatus().String()})
+	})
+}
+
+// newGateway gateway to health server on bufconn, metadata of the last call is sent to md
+func newGateway(t *testing.T, md chan<- metadata.MD, opts ...OptionFunc) *Gateway {
+	t.Helper()
+
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		in, _ := metadata.FromIncomingContext(ctx)
+		md <- in
+		return handler(ctx, req)
+	}))
+	hpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	opts = append([]OptionFunc{
+		SetTarget("passthrough:///bufnet"),
+		SetDialOptions(
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		),
+	}, opts...)
+
+	gw, err := New(healthHandler{}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { gw.Shutdown(context.Background()) })
+
+	return gw
+}
+
+func TestGatewayPrefix(t *testing.T) {
+	gw := newGateway(t, make(chan metadata.MD, 1), SetPrefix("/api"))
+
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"status\":\"SERVING\"}\n" {
+		t.Fatalf("got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// prefix is matched at segment boundary
+	rec = httptest.NewRecorder()
+	gw.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/apiv1/health", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("path outside prefix got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGatewayMetadata(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	md := make(chan metadata.MD, 1)
+	gw := newGateway(t, md, SetForwardHeaders("X-Tenant"))
+
+	// span of rest request
+	traceId, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanId, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceId, SpanID: spanId, TraceFlags: trace.FlagsSampled,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/health", nil).WithContext(ctx)
+	req.Header.Set("Traceparent", "00-11111111111111111111111111111111-2222222222222222-01")
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("X-Tenant", "acme")
+
+	rec := httptest.NewRecorder()
+	gw.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d %s", rec.Code, rec.Body.String())
+	}
+
+	in := <-md
+	if got := in.Get("traceparent"); len(got) != 1 || got[0] != "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01" {
+		t.Errorf("grpc call is not child of rest span, traceparent %v", got)
+	}
+	if got := in.Get("x-request-id"); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("request id %v", got)
+	}
+	if got := in.Get("x-tenant"); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("forwarded header %v", got)
+	}
+}
+
+func TestGatewayTarget(t *testing.T) {
+	if _, err := New(healthHandler{}); err == nil {
+		t.Fatal("gateway is created without target nor grpc server")
+	}
+}
@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	grpcserver "github.com/vizucode/gokit/factory/server/grpc"
+	"github.com/vizucode/gokit/utils/env"
+	"google.golang.org/grpc"
+)
+
+// OptionFunc setter gateway options
+type OptionFunc func(*option)
+
+type option struct {
+	// target address of grpc server, empty use local target of server
+	target      string
+	dialOptions []grpc.DialOption
+	// server grpc server of this service, set by rest server
+	server     grpcserver.Server
+	muxOptions []runtime.ServeMuxOption
+
+	// prefix path of gateway when mounted in rest server, stripped before routing
+	prefix string
+	// listenAddr serve gateway on its own listener instead of rest server
+	listenAddr string
+
+	// forwardHeaders http headers forwarded as grpc metadata with the same key. Trace context is not forwarded,
+	// it is injected by outgoingMetadata from the span of rest request so the grpc span is its child
+	forwardHeaders []string
+}
+
+func defaultOption() option {
+	return option{
+		target:     env.GetString("GRPC_GATEWAY_TARGET", ""),
+		listenAddr: env.GetString("GRPC_GATEWAY_LISTEN_ADDR", ""),
+		forwardHeaders: []string{
+			"X-Request-Id",
+			"X-Request-Timeout",
+			"X-App-Version",
+		},
+	}
+}
+
+// SetTarget set address of grpc server, required when grpc server of this service serve tls or
+// is not served by this process. Connection is insecure unless credentials are set with SetDialOptions
+func SetTarget(target string) OptionFunc {
+	return func(o *option) {
+		o.target = target
+	}
+}
+
+// SetDialOptions set dial options of connection to grpc server
+func SetDialOptions(opts ...grpc.DialOption) OptionFunc {
+	return func(o *option) {
+		o.dialOptions = opts
+	}
+}
+
+// SetServer set grpc server of this service, its address is taken from the bound listener when target is not set
+func SetServer(server grpcserver.Server) OptionFunc {
+	return func(o *option) {
+		o.server = server
+	}
+}
+
+// SetServeMuxOptions add options of gateway serve mux, e.g. custom marshaler
+func SetServeMuxOptions(opts ...runtime.ServeMuxOption) OptionFunc {
+	return func(o *option) {
+		o.muxOptions = append(o.muxOptions, opts...)
+	}
+}
+
+// SetPrefix set path prefix of gateway mounted in rest server
+func SetPrefix(prefix string) OptionFunc {
+	return func(o *option) {
+		o.prefix = prefix
+	}
+}
+
+// SetListenAddr serve gateway on its own listener instead of mounted in rest server
+func SetListenAddr(addr string) OptionFunc {
+	return func(o *option) {
+		o.listenAddr = addr
+	}
+}
+
+// SetForwardHeaders add http headers forwarded to grpc server as metadata
+func SetForwardHeaders(headers ...string) OptionFunc {
+	return func(o *option) {
+		o.forwardHeaders = append(o.forwardHeaders, headers...)
+	}
+}
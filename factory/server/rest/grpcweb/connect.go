@@ -0,0 +1,185 @@
+package grpcweb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/vizucode/gokit/utils/errorkit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	connectMarshaler   = protojson.MarshalOptions{}
+	connectUnmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// connectError error body of connect protocol
+type connectError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message,omitempty"`
+	Details []connectDetail `json:"details,omitempty"`
+}
+
+type connectDetail struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// serveConnect proxy unary call of connect protocol, json body is transcoded with message types
+// registered by generated code of the services
+func (w *Web) serveConnect(rw http.ResponseWriter, r *http.Request, contentType string) {
+	body, err := io.ReadAll(r.Body)
+	if tooLarge(err) {
+		writeConnectError(rw, status.Error(codes.ResourceExhausted, errMessageTooLarge.Error()))
+		return
+	}
+	if err != nil {
+		writeConnectError(rw, status.Error(codes.InvalidArgument, errorkit.BadRequest))
+		return
+	}
+
+	var input, output protoreflect.MessageType
+	if contentType == "application/json" {
+		if input, output, err = methodTypes(r.URL.Path); err != nil {
+			writeConnectError(rw, err)
+			return
+		}
+
+		in := input.New().Interface()
+		if len(body) > 0 {
+			if err = connectUnmarshaler.Unmarshal(body, in); err != nil {
+				writeConnectError(rw, status.Error(codes.InvalidArgument, errorkit.BadRequest))
+				return
+			}
+		}
+
+		if body, err = proto.Marshal(in); err != nil {
+			writeConnectError(rw, status.Error(codes.InvalidArgument, errorkit.BadRequest))
+			return
+		}
+	}
+
+	ctx, cancel := outgoingContext(r)
+	defer cancel()
+
+	var (
+		reply            []byte
+		header, trailers metadata.MD
+	)
+	err = w.conn.Invoke(ctx, r.URL.Path, &body, &reply, grpc.ForceCodec(rawCodec{}), grpc.Header(&header), grpc.Trailer(&trailers))
+
+	h := rw.Header()
+	for key, values := range header {
+		if key == "content-type" {
+			continue
+		}
+		for _, v := range values {
+			h.Add(key, encodeHeader(key, v))
+		}
+	}
+	for key, values := range trailers {
+		for _, v := range values {
+			h.Add("Trailer-"+key, encodeHeader(key, v))
+		}
+	}
+
+	if err != nil {
+		writeConnectError(rw, err)
+		return
+	}
+
+	if output != nil {
+		out := output.New().Interface()
+		if err = proto.Unmarshal(reply, out); err == nil {
+			reply, err = connectMarshaler.Marshal(out)
+		}
+		if err != nil {
+			writeConnectError(rw, status.Error(codes.Internal, errorkit.InternalServer))
+			return
+		}
+	}
+
+	h.Set("Content-Type", contentType)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(reply)
+}
+
+// methodTypes input and output message types of /package.Service/Method
+func methodTypes(path string) (protoreflect.MessageType, protoreflect.MessageType, error) {
+	service, method, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unimplemented, "unknown service %s", service)
+	}
+
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, nil, status.Errorf(codes.Unimplemented, "unknown service %s", service)
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, nil, status.Errorf(codes.Unimplemented, "unknown method %s of service %s", method, service)
+	}
+
+	return messageType(md.Input()), messageType(md.Output()), nil
+}
+
+func messageType(md protoreflect.MessageDescriptor) protoreflect.MessageType {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		return mt
+	}
+
+	return dynamicpb.NewMessageType(md)
+}
+
+// writeConnectError write grpc status as connect error, status code follow errorkit mapping
+func writeConnectError(rw http.ResponseWriter, err error) {
+	st := status.Convert(err)
+
+	body := connectError{
+		Code:    connectCode(st.Code()),
+		Message: st.Message(),
+	}
+	for _, detail := range st.Proto().GetDetails() {
+		body.Details = append(body.Details, connectDetail{
+			Type:  strings.TrimPrefix(detail.GetTypeUrl(), "type.googleapis.com/"),
+			Value: base64.RawStdEncoding.EncodeToString(detail.GetValue()),
+		})
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(errorkit.HTTPFromCode(st.Code()))
+	_ = json.NewEncoder(rw).Encode(body)
+}
+
+// connectCode snake case name of code, e.g. invalid_argument
+func connectCode(code codes.Code) string {
+	name := code.String()
+
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c >= 'A' && c <= 'Z' {
+			if i > 0 && name[i-1] >= 'a' && name[i-1] <= 'z' {
+				sb.WriteByte('_')
+			}
+			c += 'a' - 'A'
+		}
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
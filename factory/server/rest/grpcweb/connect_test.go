@@ -0,0 +1,80 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vizucode/gokit/utils/errorkit"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestServeConnect(t *testing.T) {
+	w := newEcho(t)
+
+	msg, _ := proto.Marshal(wrapperspb.String("a"))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/test.Echo/Unary", bytes.NewReader(msg))
+	req.Header.Set("Content-Type", "application/proto")
+	w.ServeHTTP(rec, req)
+
+	out := new(wrapperspb.StringValue)
+	if err := proto.Unmarshal(rec.Body.Bytes(), out); rec.Code != http.StatusOK || err != nil || out.GetValue() != "a" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("x-request-id") != "req-1" || rec.Header().Get("Trailer-x-checksum") != "abc" {
+		t.Fatalf("metadata is not sent, header %v", rec.Header())
+	}
+}
+
+func TestServeConnectError(t *testing.T) {
+	w := newEcho(t)
+
+	fail, _ := proto.Marshal(wrapperspb.String("fail"))
+	tests := map[string]struct {
+		contentType string
+		body        []byte
+		status      int
+		code        string
+	}{
+		"status of server":   {contentType: "application/proto", body: fail, status: http.StatusBadRequest, code: "invalid_argument"},
+		"unregistered types": {contentType: "application/json", body: []byte(`{}`), status: errorkit.HTTPFromCode(codes.Unimplemented), code: "unimplemented"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/test.Echo/Unary", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w.ServeHTTP(rec, req)
+
+			var body connectError
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status || body.Code != tt.code || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+				t.Fatalf("got %d %+v, want %d %s", rec.Code, body, tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestConnectCode(t *testing.T) {
+	cases := map[codes.Code]string{
+		codes.OK:                "ok",
+		codes.InvalidArgument:   "invalid_argument",
+		codes.DeadlineExceeded:  "deadline_exceeded",
+		codes.ResourceExhausted: "resource_exhausted",
+	}
+
+	for code, want := range cases {
+		if got := connectCode(code); got != want {
+			t.Errorf("%s: got %s, want %s", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package grpcweb
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	grpcserver "github.com/vizucode/gokit/factory/server/grpc"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/utils/request"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Web expose grpc services to browser with grpc-web and connect protocol, every call is proxied to
+// the grpc server so it is logged and traced by grpc interceptor
+type Web struct {
+	opt     option
+	conn    *grpc.ClientConn
+	server  *http.Server
+	origins map[string]struct{}
+}
+
+// New create grpc-web proxy of grpc server
+func New(opts ...OptionFunc) (*Web, error) {
+	w := &Web{opt: defaultOption()}
+	for _, o := range opts {
+		o(&w.opt)
+	}
+
+	w.origins = make(map[string]struct{}, len(w.opt.origins))
+	for _, origin := range w.opt.origins {
+		w.origins[strings.TrimSpace(origin)] = struct{}{}
+	}
+
+	target, dialOptions, err := grpcserver.ResolveTarget(w.opt.target, w.opt.dialOptions, w.opt.server)
+	if err != nil {
+		return nil, fmt.Errorf("grpc web: %w", err)
+	}
+
+	// connection is established lazily, grpc server may not be serving yet
+	if w.conn, err = grpc.NewClient(target, dialOptions...); err != nil {
+		return nil, fmt.Errorf("grpc web: dial %s: %w", target, err)
+	}
+
+	return w, nil
+}
+
+// Prefix path prefix of grpc-web mounted in rest server
+func (w *Web) Prefix() string {
+	return w.opt.prefix
+}
+
+// OwnListener grpc-web is served on its own listener instead of mounted in rest server
+func (w *Web) OwnListener() bool {
+	return w.opt.listenAddr != ""
+}
+
+// ServeHTTP serve grpc-web and connect request, path is /package.Service/Method after prefix is stripped
+func (w *Web) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if w.opt.prefix != "" {
+		path, ok := request.TrimPathPrefix(r.URL.Path, w.opt.prefix)
+		if !ok {
+			http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		r.URL.Path = path
+		r.URL.RawPath = ""
+	}
+
+	if !w.cors(rw, r) {
+		return
+	}
+
+	if r.Method == http.MethodOptions {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodPost || !isMethodPath(r.URL.Path) {
+		http.Error(rw, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	// request carry a message, length-prefixed and base64 encoded for grpc-web-text
+	r.Body = http.MaxBytesReader(rw, r.Body, int64(w.opt.maxMessageSize+frameHeaderLen)*4/3+4)
+
+	contentType := mediaType(r.Header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(contentType, "application/grpc-web"):
+		w.serveWeb(rw, r, contentType)
+	case w.opt.connect && (contentType == "application/json" || contentType == "application/proto"):
+		w.serveConnect(rw, r, contentType)
+	default:
+		http.Error(rw, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+	}
+}
+
+// Handler mount grpc-web in fiber, request which is not grpc-web nor connect is passed to next handler.
+// User context (logger, deadline and tracing) is passed to the grpc call. The response is buffered by
+// fasthttpadaptor, messages of server streaming reach the browser at once when the call end, use
+// SetListenAddr to stream them per message
+func (w *Web) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !w.accept(c) {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		fasthttpadaptor.NewFastHTTPHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			w.ServeHTTP(rw, r.WithContext(ctx))
+		}))(c.Context())
+
+		return nil
+	}
+}
+
+// Serve grpc-web on its own listener
+func (w *Web) Serve() error {
+	w.server = &http.Server{
+		Addr:              w.opt.listenAddr,
+		Handler:           w,
+		ReadHeaderTimeout: w.opt.readHeaderTimeout,
+		ReadTimeout:       w.opt.readTimeout,
+		IdleTimeout:       w.opt.idleTimeout,
+	}
+
+	logger.GreenBold(fmt.Sprintf("⇨ GRPC web run at %s\n", w.opt.listenAddr))
+	if err := w.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown stop own listener and close connection to grpc server
+func (w *Web) Shutdown(ctx context.Context) {
+	if w.server != nil {
+		_ = w.server.Shutdown(ctx)
+	}
+
+	_ = w.conn.Close()
+}
+
+// accept request of mounted handler, grpc-web or connect call and their preflight
+func (w *Web) accept(c *fiber.Ctx) bool {
+	path, ok := request.TrimPathPrefix(c.Path(), w.opt.prefix)
+	if !ok || !isMethodPath(path) {
+		return false
+	}
+
+	if c.Method() == fiber.MethodOptions {
+		return c.Get(fiber.HeaderAccessControlRequestMethod) != ""
+	}
+
+	contentType := mediaType(c.Get(fiber.HeaderContentType))
+	return strings.HasPrefix(contentType, "application/grpc-web") ||
+		(w.opt.connect && (contentType == "application/json" || contentType == "application/proto"))
+}
+
+// cors set cross-origin headers, false when the origin is not allowed. No header is set without allowed origins
+// so browser only permit same-origin call, credentials of the browser are forwarded to grpc server
+func (w *Web) cors(rw http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(w.origins) == 0 {
+		return true
+	}
+
+	if _, ok := w.origins[origin]; !ok {
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return false
+	}
+
+	h := rw.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Add("Vary", "Origin")
+
+	if r.Method == http.MethodOptions {
+		h.Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+			h.Set("Access-Control-Allow-Headers", headers)
+		}
+		h.Set("Access-Control-Max-Age", "7200")
+	}
+
+	return true
+}
+
+// outgoingContext metadata of grpc call from request headers, deadline from grpc-timeout or connect-timeout-ms
+func outgoingContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx := r.Context()
+
+	md := metadata.MD{}
+	for key, values := range r.Header {
+		key = strings.ToLower(key)
+		if _, ok := skipHeaders[key]; ok || strings.HasPrefix(key, "access-control-") || strings.HasPrefix(key, "sec-") {
+			continue
+		}
+
+		for _, v := range values {
+			if strings.HasSuffix(key, "-bin") {
+				b, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					b, err = base64.RawStdEncoding.DecodeString(v)
+				}
+				if err != nil {
+					continue
+				}
+				v = string(b)
+			}
+			md.Append(key, v)
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		md.Append("x-forwarded-for", host)
+	}
+
+	// continue trace and request id of rest logging when mounted in rest server
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	for key, v := range carrier {
+		md.Set(key, v)
+	}
+	if len(md.Get("x-request-id")) < 1 && ctx.Value(logger.LogKey) != nil {
+		md.Set("x-request-id", logger.GetRequestId(ctx))
+	}
+
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	if d, ok := requestTimeout(r); ok {
+		return context.WithTimeout(ctx, d)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// skipHeaders http headers which are not forwarded as metadata
+var skipHeaders = map[string]struct{}{
+	"host":                     {},
+	"connection":               {},
+	"content-length":           {},
+	"content-type":             {},
+	"accept":                   {},
+	"accept-encoding":          {},
+	"keep-alive":               {},
+	"te":                       {},
+	"transfer-encoding":        {},
+	"upgrade":                  {},
+	"origin":                   {},
+	"referer":                  {},
+	"cookie":                   {},
+	"grpc-timeout":             {},
+	"connect-timeout-ms":       {},
+	"connect-protocol-version": {},
+	"x-grpc-web":               {},
+}
+
+// requestTimeout remaining time budget from grpc-timeout (grpc-web) or connect-timeout-ms (connect)
+func requestTimeout(r *http.Request) (time.Duration, bool) {
+	if v := r.Header.Get("Connect-Timeout-Ms"); v != "" {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond, true
+		}
+	}
+
+	return request.ParseGrpcTimeout(r.Header.Get("Grpc-Timeout"))
+}
+
+// isMethodPath path of grpc method, /package.Service/Method. Service without package is not served
+// so rest route with json body is not taken as connect call
+func isMethodPath(path string) bool {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	return len(parts) == 2 && strings.Contains(parts[0], ".") && parts[1] != ""
+}
+
+func mediaType(contentType string) string {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
@@ -0,0 +1,125 @@
+package grpcweb
+
+import (
+	"strings"
+	"time"
+
+	grpcserver "github.com/vizucode/gokit/factory/server/grpc"
+	"github.com/vizucode/gokit/utils/env"
+	"google.golang.org/grpc"
+)
+
+// OptionFunc setter grpc-web options
+type OptionFunc func(*option)
+
+type option struct {
+	// target address of grpc server, empty use local target of server
+	target      string
+	dialOptions []grpc.DialOption
+	// server grpc server of this service, set by rest server
+	server grpcserver.Server
+
+	// prefix path of grpc-web when mounted in rest server, stripped before routing
+	prefix string
+	// listenAddr serve grpc-web on its own listener instead of rest server
+	listenAddr string
+
+	// origins allowed origin of cross-origin request, same-origin only when empty
+	origins []string
+	// connect serve unary call with connect protocol, json or binary protobuf body
+	connect bool
+
+	// maxMessageSize maximum size of request message, checked before the message is read
+	maxMessageSize int
+	// readHeaderTimeout, readTimeout and idleTimeout of own listener
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	idleTimeout       time.Duration
+}
+
+func defaultOption() option {
+	var origins []string
+	if v := env.GetString("GRPC_WEB_ALLOWED_ORIGINS", ""); v != "" {
+		origins = strings.Split(v, ",")
+	}
+
+	return option{
+		target:     env.GetString("GRPC_WEB_TARGET", ""),
+		listenAddr: env.GetString("GRPC_WEB_LISTEN_ADDR", ""),
+		origins:    origins,
+		connect:    env.GetBool("GRPC_WEB_CONNECT", true),
+
+		maxMessageSize:    env.GetInteger("GRPC_WEB_MAX_MESSAGE_SIZE", 4<<20),
+		readHeaderTimeout: env.GetDuration("GRPC_WEB_READ_HEADER_TIMEOUT", 10*time.Second),
+		readTimeout:       env.GetDuration("GRPC_WEB_READ_TIMEOUT", 30*time.Second),
+		idleTimeout:       env.GetDuration("GRPC_WEB_IDLE_TIMEOUT", 2*time.Minute),
+	}
+}
+
+// SetTarget set address of grpc server, required when grpc server of this service serve tls or
+// is not served by this process. Connection is insecure unless credentials are set with SetDialOptions
+func SetTarget(target string) OptionFunc {
+	return func(o *option) {
+		o.target = target
+	}
+}
+
+// SetDialOptions set dial options of connection to grpc server
+func SetDialOptions(opts ...grpc.DialOption) OptionFunc {
+	return func(o *option) {
+		o.dialOptions = opts
+	}
+}
+
+// SetServer set grpc server of this service, its address is taken from the bound listener when target is not set
+func SetServer(server grpcserver.Server) OptionFunc {
+	return func(o *option) {
+		o.server = server
+	}
+}
+
+// SetPrefix set path prefix of grpc-web mounted in rest server
+func SetPrefix(prefix string) OptionFunc {
+	return func(o *option) {
+		o.prefix = prefix
+	}
+}
+
+// SetListenAddr serve grpc-web on its own listener instead of mounted in rest server, required by server
+// streaming which reach the browser per message, mounted handler send the whole response when the call end
+func SetListenAddr(addr string) OptionFunc {
+	return func(o *option) {
+		o.listenAddr = addr
+	}
+}
+
+// SetAllowedOrigins set allowed origin of cross-origin request, only same-origin request is permitted by browser
+// when no origin is allowed
+func SetAllowedOrigins(origins ...string) OptionFunc {
+	return func(o *option) {
+		o.origins = origins
+	}
+}
+
+// SetConnect serve unary call with connect protocol (application/json and application/proto), enabled by default
+func SetConnect(enable bool) OptionFunc {
+	return func(o *option) {
+		o.connect = enable
+	}
+}
+
+// SetMaxMessageSize set maximum size of request message, default 4 MiB as grpc server
+func SetMaxMessageSize(size int) OptionFunc {
+	return func(o *option) {
+		o.maxMessageSize = size
+	}
+}
+
+// SetServerTimeouts set timeouts of own listener, write timeout is not set so server streaming is not cut
+func SetServerTimeouts(readHeader, read, idle time.Duration) OptionFunc {
+	return func(o *option) {
+		o.readHeaderTimeout = readHeader
+		o.readTimeout = read
+		o.idleTimeout = idle
+	}
+}
@@ -0,0 +1,57 @@
+package grpcweb_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vizucode/gokit/factory/server"
+	gokitrpc "github.com/vizucode/gokit/factory/server/grpc"
+	"github.com/vizucode/gokit/factory/server/rest/grpcweb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type handler struct{}
+
+func (handler) Register(*grpc.Server) {}
+
+func TestLocalServer(t *testing.T) {
+	svc := server.NewService(server.SetServiceName("test"), server.SetGrpcHandler(handler{}))
+	srv := gokitrpc.New(svc, gokitrpc.SetListener(bufconn.Listen(1<<20)), gokitrpc.SetHealth(true))
+	go srv.Serve()
+	defer srv.Shutdown(context.Background())
+
+	w, err := grpcweb.New(grpcweb.SetServer(srv), grpcweb.SetPrefix("/rpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Shutdown(context.Background())
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc/grpc.health.v1.Health/Check", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"SERVING"`) {
+		t.Fatalf("got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// prefix is matched at segment boundary
+	req = httptest.NewRequest(http.MethodPost, "/rpcx/grpc.health.v1.Health/Check", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("path outside prefix got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTargetRequired(t *testing.T) {
+	if _, err := grpcweb.New(); err == nil {
+		t.Fatal("grpc-web is created without target nor grpc server")
+	}
+}
@@ -0,0 +1,258 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// frameHeaderLen flag byte and message length of length-prefixed frame
+	frameHeaderLen = 5
+	// frameTrailer flag of frame carrying trailers in grpc-web response
+	frameTrailer = 0x80
+)
+
+// rawCodec pass serialized message through without decoding, the proxy does not need the message types
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("grpc web: unexpected message type %T", v)
+	}
+
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("grpc web: unexpected message type %T", v)
+	}
+
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// proxyStream allow unary, server and client streaming call, grpc-web client send all messages at once
+var proxyStream = &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
+
+// serveWeb proxy grpc-web call, grpc-web-text body is base64 encoded
+func (w *Web) serveWeb(rw http.ResponseWriter, r *http.Request, contentType string) {
+	text := strings.HasPrefix(contentType, "application/grpc-web-text")
+
+	var body io.Reader = r.Body
+	if text {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	out := &webWriter{rw: rw, text: text}
+
+	messages, err := readFrames(body, w.opt.maxMessageSize)
+	if tooLarge(err) {
+		rw.Header().Set("Content-Type", contentType)
+		out.writeTrailer(nil, status.Error(codes.ResourceExhausted, err.Error()))
+		return
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := outgoingContext(r)
+	defer cancel()
+
+	rw.Header().Set("Content-Type", contentType)
+
+	stream, err := w.conn.NewStream(ctx, proxyStream, r.URL.Path, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		out.writeTrailer(nil, err)
+		return
+	}
+
+	for i := range messages {
+		if err = stream.SendMsg(&messages[i]); err != nil {
+			break
+		}
+	}
+	_ = stream.CloseSend()
+
+	header, _ := stream.Header()
+	out.writeHeader(header)
+
+	for {
+		var msg []byte
+		if err = stream.RecvMsg(&msg); err != nil {
+			break
+		}
+		out.writeFrame(0, msg)
+	}
+
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	out.writeTrailer(stream.Trailer(), err)
+}
+
+// errMessageTooLarge request message is larger than maximum message size
+var errMessageTooLarge = errors.New("grpc web: message is larger than maximum message size")
+
+// tooLarge message or request body exceed the limit
+func tooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.Is(err, errMessageTooLarge) || errors.As(err, &maxBytesErr)
+}
+
+// readFrames read length-prefixed messages of request body, claimed length is checked before allocated
+func readFrames(r io.Reader, maxSize int) ([][]byte, error) {
+	var messages [][]byte
+
+	header := make([]byte, frameHeaderLen)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return messages, nil
+			}
+			return nil, fmt.Errorf("grpc web: malformed frame: %w", err)
+		}
+
+		if header[0]&frameTrailer != 0 {
+			return nil, errors.New("grpc web: unexpected trailer frame in request")
+		}
+
+		size := binary.BigEndian.Uint32(header[1:])
+		if uint64(size) > uint64(maxSize) {
+			return nil, fmt.Errorf("%w: %d > %d", errMessageTooLarge, size, maxSize)
+		}
+
+		msg := make([]byte, size)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return nil, fmt.Errorf("grpc web: malformed frame: %w", err)
+		}
+
+		messages = append(messages, msg)
+	}
+}
+
+// webWriter write grpc-web response, every frame is flushed so server streaming reach the browser per message.
+// Mounted in rest server the response is buffered by fasthttpadaptor and sent when the call end
+type webWriter struct {
+	rw          http.ResponseWriter
+	text        bool
+	wroteHeader bool
+}
+
+func (o *webWriter) writeHeader(md metadata.MD) {
+	if o.wroteHeader {
+		return
+	}
+	o.wroteHeader = true
+
+	h := o.rw.Header()
+	exposed := []string{"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin"}
+	for key, values := range md {
+		if key == "content-type" {
+			continue
+		}
+		for _, v := range values {
+			h.Add(key, encodeHeader(key, v))
+		}
+		exposed = append(exposed, key)
+	}
+
+	if h.Get("Access-Control-Allow-Origin") != "" {
+		h.Set("Access-Control-Expose-Headers", strings.Join(exposed, ", "))
+	}
+
+	o.rw.WriteHeader(http.StatusOK)
+}
+
+func (o *webWriter) writeFrame(flag byte, payload []byte) {
+	frame := make([]byte, frameHeaderLen+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	copy(frame[frameHeaderLen:], payload)
+
+	if o.text {
+		frame = []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+
+	_, _ = o.rw.Write(frame)
+	if f, ok := o.rw.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// writeTrailer write status and trailer metadata as the last frame
+func (o *webWriter) writeTrailer(md metadata.MD, err error) {
+	o.writeHeader(nil)
+
+	st := status.Convert(err)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "grpc-status: %d\r\n", st.Code())
+	if st.Message() != "" {
+		fmt.Fprintf(&buf, "grpc-message: %s\r\n", encodeMessage(st.Message()))
+	}
+	if st.Code() != codes.OK && len(st.Proto().GetDetails()) > 0 {
+		if details, err := proto.Marshal(st.Proto()); err == nil {
+			fmt.Fprintf(&buf, "grpc-status-details-bin: %s\r\n", base64.RawStdEncoding.EncodeToString(details))
+		}
+	}
+
+	// trailers-only response carry headers in trailer metadata
+	keys := make([]string, 0, len(md))
+	for key := range md {
+		if key != "content-type" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, v := range md[key] {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, encodeHeader(key, v))
+		}
+	}
+
+	o.writeFrame(frameTrailer, buf.Bytes())
+}
+
+// encodeHeader binary metadata is base64 encoded
+func encodeHeader(key, value string) string {
+	if strings.HasSuffix(key, "-bin") {
+		return base64.RawStdEncoding.EncodeToString([]byte(value))
+	}
+
+	return value
+}
+
+// encodeMessage percent encode grpc-message, byte outside printable ascii and percent sign
+func encodeMessage(msg string) string {
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if c := msg[i]; c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,288 @@
+package grpcweb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestReadFramesTooLarge(t *testing.T) {
+	// frame header claim 4 GiB message without any payload
+	body := []byte{0, 0xff, 0xff, 0xff, 0xff}
+
+	if _, err := readFrames(bytes.NewReader(body), 4<<20); !errors.Is(err, errMessageTooLarge) {
+		t.Fatalf("expected errMessageTooLarge, got %v", err)
+	}
+}
+
+func TestServeTooLarge(t *testing.T) {
+	w, err := New(SetTarget("passthrough:///unused"), SetMaxMessageSize(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.conn.Close()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/test.Echo/Unary", bytes.NewReader([]byte{0, 0, 0, 0, 9}))
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	w.ServeHTTP(rec, req)
+
+	if !strings.Contains(rec.Body.String(), "grpc-status: 8") {
+		t.Fatalf("expected resource exhausted trailer, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/test.Echo/Unary", strings.NewReader(`{"value":"`+strings.Repeat("a", 64)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "resource_exhausted") {
+		t.Fatalf("expected resource exhausted error, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+// echoService unary and server streaming methods of wrapperspb.StringValue, value "fail" return invalid argument
+var echoService = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Unary",
+		Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(wrapperspb.StringValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+
+			_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", "req-1"))
+			_ = grpc.SetTrailer(ctx, metadata.Pairs("x-checksum", "abc"))
+			if in.GetValue() == "fail" {
+				return nil, status.Error(codes.InvalidArgument, "value 100% invalid")
+			}
+			return in, nil
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Repeat",
+		ServerStreams: true,
+		Handler: func(_ interface{}, stream grpc.ServerStream) error {
+			in := new(wrapperspb.StringValue)
+			if err := stream.RecvMsg(in); err != nil {
+				return err
+			}
+			for i := 0; i < 3; i++ {
+				if err := stream.SendMsg(in); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}},
+}
+
+// newEcho grpc-web proxy of echo service served on bufconn
+func newEcho(t *testing.T) *Web {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	srv.RegisterService(&echoService, nil)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	w, err := New(SetTarget("passthrough:///bufnet"), SetDialOptions(
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = w.conn.Close() })
+
+	return w
+}
+
+// base64Chunk base64 text ended by padding
+var base64Chunk = regexp.MustCompile(`[A-Za-z0-9+/]+=*`)
+
+// frame length-prefixed message of grpc-web body
+func frame(flag byte, payload []byte) []byte {
+	b := make([]byte, frameHeaderLen, frameHeaderLen+len(payload))
+	b[0] = flag
+	binary.BigEndian.PutUint32(b[1:], uint32(len(payload)))
+
+	return append(b, payload...)
+}
+
+// parseResponse split grpc-web response into messages and trailer
+func parseResponse(t *testing.T, body []byte) ([]string, string) {
+	t.Helper()
+
+	var (
+		messages []string
+		trailer  string
+	)
+	for len(body) > 0 {
+		if len(body) < frameHeaderLen {
+			t.Fatalf("malformed frame %q", body)
+		}
+		size := int(binary.BigEndian.Uint32(body[1:frameHeaderLen]))
+		payload := body[frameHeaderLen : frameHeaderLen+size]
+
+		if body[0]&frameTrailer != 0 {
+			trailer = string(payload)
+		} else {
+			msg := new(wrapperspb.StringValue)
+			if err := proto.Unmarshal(payload, msg); err != nil {
+				t.Fatal(err)
+			}
+			messages = append(messages, msg.GetValue())
+		}
+		body = body[frameHeaderLen+size:]
+	}
+
+	return messages, trailer
+}
+
+func TestReadFrames(t *testing.T) {
+	body := append(frame(0, []byte("a")), frame(0, nil)...)
+	body = append(body, frame(0, []byte("bc"))...)
+
+	messages, err := readFrames(bytes.NewReader(body), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || string(messages[0]) != "a" || len(messages[1]) != 0 || string(messages[2]) != "bc" {
+		t.Fatalf("unexpected messages %q", messages)
+	}
+
+	if _, err = readFrames(bytes.NewReader(frame(0, []byte("abc"))[:6]), 8); err == nil {
+		t.Fatal("truncated frame is accepted")
+	}
+	if _, err = readFrames(bytes.NewReader(frame(frameTrailer, nil)), 8); err == nil {
+		t.Fatal("trailer frame in request is accepted")
+	}
+}
+
+func TestWriteTrailer(t *testing.T) {
+	rec := httptest.NewRecorder()
+	out := &webWriter{rw: rec}
+	out.writeTrailer(metadata.Pairs("x-b", "2", "x-a", "1", "x-data-bin", "\x00\x01"), status.Error(codes.NotFound, "order 100% missing"))
+
+	_, trailer := parseResponse(t, rec.Body.Bytes())
+	want := "grpc-status: 5\r\n" +
+		"grpc-message: order 100%25 missing\r\n" +
+		"x-a: 1\r\n" +
+		"x-b: 2\r\n" +
+		"x-data-bin: AAE\r\n"
+	if trailer != want {
+		t.Fatalf("got %q, want %q", trailer, want)
+	}
+}
+
+func TestServeWeb(t *testing.T) {
+	w := newEcho(t)
+
+	tests := map[string]struct {
+		path, value, contentType string
+		messages                 []string
+		// trailer prefix of trailer frame, unary call also send header and trailer metadata
+		trailer string
+	}{
+		"unary":            {path: "/test.Echo/Unary", value: "a", contentType: "application/grpc-web+proto", messages: []string{"a"}, trailer: "grpc-status: 0\r\n"},
+		"server streaming": {path: "/test.Echo/Repeat", value: "b", contentType: "application/grpc-web+proto", messages: []string{"b", "b", "b"}, trailer: "grpc-status: 0\r\n"},
+		"text":             {path: "/test.Echo/Unary", value: "c", contentType: "application/grpc-web-text", messages: []string{"c"}, trailer: "grpc-status: 0\r\n"},
+		"error":            {path: "/test.Echo/Unary", value: "fail", contentType: "application/grpc-web+proto", trailer: "grpc-status: 3\r\ngrpc-message: value 100%25 invalid\r\n"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			msg, _ := proto.Marshal(wrapperspb.String(tt.value))
+			body := frame(0, msg)
+			if strings.HasPrefix(tt.contentType, "application/grpc-web-text") {
+				body = []byte(base64.StdEncoding.EncodeToString(body))
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader(body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w.ServeHTTP(rec, req)
+
+			resBody := rec.Body.Bytes()
+			if strings.HasPrefix(tt.contentType, "application/grpc-web-text") {
+				// every frame is encoded on its own, padding end a chunk
+				var decoded []byte
+				for _, chunk := range base64Chunk.FindAllString(string(resBody), -1) {
+					b, err := base64.StdEncoding.DecodeString(chunk)
+					if err != nil {
+						t.Fatalf("decode %q: %v", chunk, err)
+					}
+					decoded = append(decoded, b...)
+				}
+				resBody = decoded
+			}
+
+			messages, trailer := parseResponse(t, resBody)
+			if strings.Join(messages, ",") != strings.Join(tt.messages, ",") {
+				t.Fatalf("got messages %q, want %q", messages, tt.messages)
+			}
+			if !strings.HasPrefix(trailer, tt.trailer) {
+				t.Fatalf("got trailer %q, want prefix %q", trailer, tt.trailer)
+			}
+			if tt.path == "/test.Echo/Unary" && (!strings.Contains(trailer, "x-checksum: abc") || rec.Header().Get("x-request-id") != "req-1") {
+				t.Fatalf("metadata is not sent, header %v, trailer %q", rec.Header(), trailer)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	tests := map[string]struct {
+		origins []string
+		origin  string
+		code    int
+		allow   string
+	}{
+		"same-origin only": {origin: "https://evil.example", code: http.StatusNoContent},
+		"allowed":          {origins: []string{"https://app.example"}, origin: "https://app.example", code: http.StatusNoContent, allow: "https://app.example"},
+		"not allowed":      {origins: []string{"https://app.example"}, origin: "https://evil.example", code: http.StatusForbidden},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w, err := New(SetTarget("passthrough:///unused"), SetAllowedOrigins(tt.origins...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.conn.Close()
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodOptions, "/test.Echo/Unary", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			w.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code || rec.Header().Get("Access-Control-Allow-Origin") != tt.allow {
+				t.Fatalf("got %d, allow origin %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package rest
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -13,14 +15,16 @@ import (
 	"github.com/google/uuid"
 	"github.com/vizucode/gokit/logger"
 	"github.com/vizucode/gokit/tracer"
+	"github.com/vizucode/gokit/utils/certificate"
+	"github.com/vizucode/gokit/utils/errorkit"
+	"github.com/vizucode/gokit/utils/recovery"
 	"github.com/vizucode/gokit/utils/timezone"
 )
 
-func (r *rest) restTraceLogger(c *fiber.Ctx) error {
+func (r *rest) restTraceLogger(c *fiber.Ctx) (err error) {
 	ctx := c.UserContext()
 	start := time.Now().In(timezone.JakartaTz())
 
-	var err error
 	var sc = http.StatusOK
 	var resp string
 
@@ -52,8 +56,14 @@ func (r *rest) restTraceLogger(c *fiber.Ctx) error {
 	operationName := fmt.Sprintf("%s %s", c.Method(), parseUrl)
 	trace, ctx := tracer.StartTraceWithContext(ctx, operationName)
 	defer func() {
+		var clientErr error
 		if re := recover(); re != nil {
-			err = fmt.Errorf("%s", re)
+			p := recovery.New(dl.Type.String(), dl.Service, parseUrl, re)
+			p.Record(ctx, trace)
+
+			// the panic is kept on logging, client only receive sanitized internal server error
+			err, sc, resp = p, http.StatusInternalServerError, errorkit.InternalServer
+			clientErr = errorkit.Error(errors.New(errorkit.InternalServer), errorkit.InternalServer, http.StatusInternalServerError)
 		}
 
 		if err != nil {
@@ -70,6 +80,10 @@ func (r *rest) restTraceLogger(c *fiber.Ctx) error {
 		dl.Finalize(ctx)
 		// finish the tracing
 		trace.Finish()
+
+		if clientErr != nil {
+			err = clientErr
+		}
 	}()
 
 	// set logger into context with key LogKey
@@ -77,6 +91,13 @@ func (r *rest) restTraceLogger(c *fiber.Ctx) error {
 	ctx = context.WithValue(ctx, logger.LogKey, lock)
 	lock.Set(logger.RequestId, dl.RequestId)
 
+	// expose verified client certificate of mutual tls to handler and logging
+	if id, ok := certificate.IdentityFromState(c.Context().TLSConnectionState()); ok {
+		ctx = certificate.WithIdentity(ctx, id)
+		logger.Tag(ctx, "client_identity", id.Subject)
+		trace.SetTag("tls.client_subject", id.Subject)
+	}
+
 	// set current context into fiber-context
 	c.SetUserContext(ctx)
 
@@ -95,10 +116,19 @@ func (r *rest) restTraceLogger(c *fiber.Ctx) error {
 	trace.SetTag("user_code", dl.UserCode)
 	trace.SetTag("device", dl.Device)
 
+	// log protobuf request decoded by Bind as json
+	if body, ok := loggedMessage(c, localRequestMessage, c.Get(fiber.HeaderContentType), c.Body()); ok {
+		dl.RequestBody = body
+		trace.SetTag("http.request_body", body)
+	}
+
 	// set open tracing response
 	trace.SetTag("http.status_code", c.Response().StatusCode())
 	sc = c.Response().StatusCode()
 	var respBody = c.Response().Body()
+	if body, ok := loggedMessage(c, localResponseMessage, string(c.Response().Header.ContentType()), respBody); ok {
+		respBody = []byte(body)
+	}
 	if len(respBody) > 1000 {
 		trace.SetTag("response.body", "success request")
 		trace.SetTag("response.body.size", len(respBody))
@@ -143,6 +173,11 @@ func parseUrl(c *fiber.Ctx) string {
 func dumpBodyFromRequest(c *fiber.Ctx) string {
 	var reqBody string
 
+	// binary protobuf is logged as base64 until it is decoded by Bind
+	if isProtobuf(c.Get(fiber.HeaderContentType)) {
+		return base64.StdEncoding.EncodeToString(c.Body())
+	}
+
 	// NOTES:
 	// - before version v1.1.0, only support formValue with key 'content' (application/x-www-formurlencoded)
 	// - after version v1.1.0, support both. form-value with key 'content' or raw json
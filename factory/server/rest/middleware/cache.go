@@ -0,0 +1,318 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/vizucode/gokit/adapter/dbc"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/tracer"
+)
+
+const (
+	cacheTagPrefix = "http-cache-tag"
+
+	cacheHit  = "hit"
+	cacheMiss = "miss"
+)
+
+type OptionCache func(o *optionCache)
+
+type optionCache struct {
+	keyPrefix string
+	// ttl default time to live of cached response
+	ttl time.Duration
+	// routeTTL time to live by registered route path, e.g. /v1/products/:id
+	routeTTL map[string]time.Duration
+	// varyHeaders request headers become part of cache key besides Accept
+	varyHeaders []string
+	// credentials cache request with Authorization or Cookie header
+	credentials bool
+	// varyBy additional cache key, e.g. return user code to cache per user
+	varyBy func(c *fiber.Ctx) string
+	// tags used to invalidate cached response with InvalidateCacheTags
+	tags func(c *fiber.Ctx) []string
+}
+
+// scanner cache client which iterate keys incrementally, implemented by redis client
+type scanner interface {
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *goredis.ScanCmd
+}
+
+// cacheEntry stored value into redis
+type cacheEntry struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+	Body        []byte `json:"body"`
+}
+
+func defaultCache() optionCache {
+	return optionCache{
+		keyPrefix: "http-cache",
+		ttl:       time.Minute,
+		routeTTL:  make(map[string]time.Duration),
+	}
+}
+
+// NewCache caches GET responses into redis (cache-aside) and supports ETag / If-None-Match.
+// Only 200 responses without Cache-Control no-store, no-cache or private are cached, request with Authorization
+// or Cookie header is not cached unless enabled with SetCacheCredentials. Response is cached per Accept header
+func NewCache(rdb *dbc.RedisDBc, options ...OptionCache) fiber.Handler {
+	opt := defaultCache()
+	for _, o := range options {
+		o(&opt)
+	}
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != http.MethodGet {
+			return c.Next()
+		}
+
+		// response of authenticated request may be personal, do not share it between users
+		if !opt.credentials && (c.Get(fiber.HeaderAuthorization) != "" || c.Get(fiber.HeaderCookie) != "") {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		trace, ctx := tracer.StartTraceWithContext(ctx, fmt.Sprintf("RestCache:%s", c.Path()))
+		defer trace.Finish()
+
+		key := opt.redisKey(c)
+		trace.SetTag("cache.key", key)
+
+		buf, err := rdb.DB.Get(ctx, key).Bytes()
+		if err != nil && !errors.Is(err, goredis.Nil) {
+			// redis is unavailable, serve request without cache
+			trace.SetError(err)
+			logger.Log.Errorf(ctx, "cache: failed to get %s: %s", key, err)
+		}
+
+		var entry cacheEntry
+		if err == nil && json.Unmarshal(buf, &entry) == nil {
+			trace.SetTag("cache.status", cacheHit)
+			logger.Tag(ctx, "cache", cacheHit)
+
+			c.Set(fiber.HeaderETag, entry.ETag)
+			c.Set("X-Cache", cacheHit)
+			if matchETag(c.Get(fiber.HeaderIfNoneMatch), entry.ETag) {
+				return c.SendStatus(http.StatusNotModified)
+			}
+
+			c.Set(fiber.HeaderContentType, entry.ContentType)
+			return c.Status(entry.StatusCode).Send(entry.Body)
+		}
+
+		trace.SetTag("cache.status", cacheMiss)
+		logger.Tag(ctx, "cache", cacheMiss)
+		c.Set("X-Cache", cacheMiss)
+
+		if err = c.Next(); err != nil {
+			return err
+		}
+
+		if c.Response().StatusCode() != http.StatusOK {
+			return nil
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		entry = cacheEntry{
+			StatusCode:  http.StatusOK,
+			ContentType: string(c.Response().Header.ContentType()),
+			ETag:        etag(body),
+			Body:        body,
+		}
+		c.Set(fiber.HeaderETag, entry.ETag)
+
+		if ttl := opt.ttlOf(c); ttl > 0 && sharedCacheable(string(c.Response().Header.Peek(fiber.HeaderCacheControl))) {
+			if err = storeCache(ctx, rdb, key, entry, ttl, opt.tagsOf(c)); err != nil {
+				trace.SetError(err)
+				logger.Log.Errorf(ctx, "cache: failed to store %s: %s", key, err)
+			}
+		}
+
+		if matchETag(c.Get(fiber.HeaderIfNoneMatch), entry.ETag) {
+			c.Response().ResetBody()
+			return c.SendStatus(http.StatusNotModified)
+		}
+
+		return nil
+	}
+}
+
+// InvalidateCacheTags delete all cached responses marked with the given tags, markers are iterated with SCAN
+// so the cache client must implement Scan
+func InvalidateCacheTags(ctx context.Context, rdb *dbc.RedisDBc, tags ...string) error {
+	scan, ok := rdb.DB.(scanner)
+	if !ok {
+		return fmt.Errorf("cache: client %T does not implement Scan", rdb.DB)
+	}
+
+	for _, tag := range tags {
+		prefix := fmt.Sprintf("%s:%s:", cacheTagPrefix, tag)
+
+		var cursor uint64
+		for {
+			markers, next, err := scan.Scan(ctx, cursor, prefix+"*", 100).Result()
+			if err != nil {
+				return err
+			}
+
+			for _, marker := range markers {
+				if err = rdb.DB.Del(ctx, strings.TrimPrefix(marker, prefix), marker).Err(); err != nil {
+					return err
+				}
+			}
+
+			if cursor = next; cursor == 0 {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func storeCache(ctx context.Context, rdb *dbc.RedisDBc, key string, entry cacheEntry, ttl time.Duration, tags []string) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err = rdb.DB.Set(ctx, key, buf, ttl).Err(); err != nil {
+		return err
+	}
+
+	// marker key per tag, the suffix is the cached key to be deleted on invalidation
+	for _, tag := range tags {
+		if err = rdb.DB.Set(ctx, fmt.Sprintf("%s:%s:%s", cacheTagPrefix, tag, key), 1, ttl).Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o optionCache) redisKey(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.OriginalURL()))
+	h.Write([]byte("|" + fiber.HeaderAccept + "=" + c.Get(fiber.HeaderAccept)))
+
+	for _, header := range o.varyHeaders {
+		h.Write([]byte("|" + header + "=" + c.Get(header)))
+	}
+
+	if o.varyBy != nil {
+		h.Write([]byte("|" + o.varyBy(c)))
+	}
+
+	return fmt.Sprintf("%s:%s", o.keyPrefix, hex.EncodeToString(h.Sum(nil)))
+}
+
+func (o optionCache) ttlOf(c *fiber.Ctx) time.Duration {
+	// after c.Next the route is the matched handler route
+	if ttl, ok := o.routeTTL[c.Route().Path]; ok {
+		return ttl
+	}
+
+	return o.ttl
+}
+
+func (o optionCache) tagsOf(c *fiber.Ctx) []string {
+	if o.tags == nil {
+		return nil
+	}
+
+	return o.tags(c)
+}
+
+// sharedCacheable whether response with the Cache-Control can be stored in shared cache
+func sharedCacheable(cacheControl string) bool {
+	for _, directive := range strings.Split(strings.ToLower(cacheControl), ",") {
+		switch strings.TrimSpace(strings.SplitN(directive, "=", 2)[0]) {
+		case "no-store", "no-cache", "private":
+			return false
+		}
+	}
+
+	return true
+}
+
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func matchETag(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetCacheKeyPrefix set prefix of redis key
+func SetCacheKeyPrefix(prefix string) OptionCache {
+	return func(o *optionCache) {
+		o.keyPrefix = prefix
+	}
+}
+
+// SetCacheTTL set default time to live of cached response
+func SetCacheTTL(ttl time.Duration) OptionCache {
+	return func(o *optionCache) {
+		o.ttl = ttl
+	}
+}
+
+// SetCacheRouteTTL set time to live for registered route path, zero ttl disable cache for the route
+func SetCacheRouteTTL(route string, ttl time.Duration) OptionCache {
+	return func(o *optionCache) {
+		o.routeTTL[route] = ttl
+	}
+}
+
+// SetCacheVaryHeaders set request headers become part of cache key, Accept is always part of the key
+func SetCacheVaryHeaders(headers ...string) OptionCache {
+	return func(o *optionCache) {
+		o.varyHeaders = headers
+	}
+}
+
+// SetCacheVaryBy set additional cache key, e.g. user code to cache response per user
+func SetCacheVaryBy(varyBy func(c *fiber.Ctx) string) OptionCache {
+	return func(o *optionCache) {
+		o.varyBy = varyBy
+	}
+}
+
+// SetCacheCredentials cache request with Authorization or Cookie header, use SetCacheVaryBy
+// to cache response per user when the response is personal
+func SetCacheCredentials(enable bool) OptionCache {
+	return func(o *optionCache) {
+		o.credentials = enable
+	}
+}
+
+// SetCacheTags set tags of cached response to be invalidated with InvalidateCacheTags
+func SetCacheTags(tags func(c *fiber.Ctx) []string) OptionCache {
+	return func(o *optionCache) {
+		o.tags = tags
+	}
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/adapter/dbc"
+)
+
+// newCacheApp serve cached /products, calls count handler execution
+func newCacheApp(rdb *dbc.RedisDBc, calls *int, cacheControl string, opts ...OptionCache) *fiber.App {
+	app := fiber.New()
+	app.Use(NewCache(rdb, opts...))
+	app.Get("/products", func(c *fiber.Ctx) error {
+		*calls++
+		if cacheControl != "" {
+			c.Set(fiber.HeaderCacheControl, cacheControl)
+		}
+
+		if c.Get(fiber.HeaderAccept) == "application/x-protobuf" {
+			c.Set(fiber.HeaderContentType, "application/x-protobuf")
+			return c.Send([]byte{0x0a, 0x01, 0x61})
+		}
+
+		return c.JSON(fiber.Map{"name": "a"})
+	})
+
+	return app
+}
+
+func cacheRequest(t *testing.T, app *fiber.App, headers map[string]string) (*http.Response, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(fiber.MethodGet, "/products", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	return res, string(body)
+}
+
+func TestCacheHit(t *testing.T) {
+	var calls int
+	app := newCacheApp(&dbc.RedisDBc{DB: newMemoryCache()}, &calls, "")
+
+	res, body := cacheRequest(t, app, nil)
+	if res.Header.Get("X-Cache") != cacheMiss || body != `{"name":"a"}` {
+		t.Fatalf("first request: cache %q, body %s", res.Header.Get("X-Cache"), body)
+	}
+
+	res, body = cacheRequest(t, app, nil)
+	if res.Header.Get("X-Cache") != cacheHit || body != `{"name":"a"}` || res.Header.Get(fiber.HeaderContentType) != fiber.MIMEApplicationJSON {
+		t.Fatalf("second request: cache %q, body %s", res.Header.Get("X-Cache"), body)
+	}
+
+	res, _ = cacheRequest(t, app, map[string]string{fiber.HeaderIfNoneMatch: res.Header.Get(fiber.HeaderETag)})
+	if res.StatusCode != http.StatusNotModified {
+		t.Fatalf("status %d, want not modified", res.StatusCode)
+	}
+
+	if calls != 1 {
+		t.Fatalf("handler is called %d times", calls)
+	}
+}
+
+func TestCacheAccept(t *testing.T) {
+	var calls int
+	app := newCacheApp(&dbc.RedisDBc{DB: newMemoryCache()}, &calls, "")
+
+	cacheRequest(t, app, map[string]string{fiber.HeaderAccept: fiber.MIMEApplicationJSON})
+
+	res, body := cacheRequest(t, app, map[string]string{fiber.HeaderAccept: "application/x-protobuf"})
+	if res.Header.Get("X-Cache") != cacheMiss || body != "\x0a\x01\x61" {
+		t.Fatalf("protobuf client is served cached json: cache %q, body %q", res.Header.Get("X-Cache"), body)
+	}
+
+	if calls != 2 {
+		t.Fatalf("handler is called %d times", calls)
+	}
+}
+
+func TestCacheSkip(t *testing.T) {
+	tests := map[string]struct {
+		headers      map[string]string
+		cacheControl string
+	}{
+		"authorization": {headers: map[string]string{fiber.HeaderAuthorization: "Bearer a"}},
+		"cookie":        {headers: map[string]string{fiber.HeaderCookie: "session=a"}},
+		"private":       {cacheControl: "private, max-age=60"},
+		"no-cache":      {cacheControl: "no-cache"},
+		"no-store":      {cacheControl: "no-store"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			app := newCacheApp(&dbc.RedisDBc{DB: newMemoryCache()}, &calls, tt.cacheControl)
+
+			cacheRequest(t, app, tt.headers)
+			if res, _ := cacheRequest(t, app, tt.headers); res.Header.Get("X-Cache") == cacheHit {
+				t.Fatal("response is served from cache")
+			}
+
+			if calls != 2 {
+				t.Fatalf("handler is called %d times", calls)
+			}
+		})
+	}
+}
+
+func TestCacheCredentials(t *testing.T) {
+	var calls int
+	app := newCacheApp(&dbc.RedisDBc{DB: newMemoryCache()}, &calls, "", SetCacheCredentials(true),
+		SetCacheVaryHeaders(fiber.HeaderAuthorization))
+
+	cacheRequest(t, app, map[string]string{fiber.HeaderAuthorization: "Bearer a"})
+	cacheRequest(t, app, map[string]string{fiber.HeaderAuthorization: "Bearer b"})
+	if res, _ := cacheRequest(t, app, map[string]string{fiber.HeaderAuthorization: "Bearer a"}); res.Header.Get("X-Cache") != cacheHit {
+		t.Fatal("response of the same user is not cached")
+	}
+
+	if calls != 2 {
+		t.Fatalf("handler is called %d times", calls)
+	}
+}
+
+func TestInvalidateCacheTags(t *testing.T) {
+	var calls int
+	rdb := &dbc.RedisDBc{DB: newMemoryCache()}
+	app := newCacheApp(rdb, &calls, "", SetCacheTags(func(*fiber.Ctx) []string { return []string{"product"} }))
+
+	cacheRequest(t, app, nil)
+	if err := InvalidateCacheTags(context.Background(), rdb, "product"); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, _ := cacheRequest(t, app, nil); res.Header.Get("X-Cache") != cacheMiss {
+		t.Fatal("invalidated response is served from cache")
+	}
+
+	if err := InvalidateCacheTags(context.Background(), &dbc.RedisDBc{DB: plainCache{}}, "product"); err == nil {
+		t.Fatal("cache client without Scan is accepted")
+	}
+}
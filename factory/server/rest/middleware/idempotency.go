@@ -0,0 +1,298 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/vizucode/gokit/adapter/dbc"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/utils/errorkit"
+)
+
+const (
+	idempotencyProcessing = "processing"
+	idempotencyCompleted  = "completed"
+
+	// idempotencyWriteTimeout timeout of storing or releasing key after the handler, the request may be cancelled by then
+	idempotencyWriteTimeout = 5 * time.Second
+)
+
+type OptionIdempotency func(o *optionIdempotency)
+
+type optionIdempotency struct {
+	header    string
+	keyPrefix string
+	methods   []string
+	// lifetime how long the stored response can be replayed
+	lifetime time.Duration
+	// lockTimeout how long the key is held after the process holding it die, extended while the handler runs
+	lockTimeout time.Duration
+	// required reject mutations without idempotency key
+	required bool
+	// scope used to separate key between users, e.g. return user id from token
+	scope func(c *fiber.Ctx) string
+}
+
+// idempotencyRecord stored value into redis
+type idempotencyRecord struct {
+	State       string              `json:"state"`
+	Fingerprint string              `json:"fingerprint"`
+	StatusCode  int                 `json:"status_code"`
+	Headers     map[string][]string `json:"headers"`
+	Body        []byte              `json:"body"`
+}
+
+// locker cache client which take a key only when it is absent, implemented by redis client
+type locker interface {
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *goredis.BoolCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *goredis.BoolCmd
+}
+
+func defaultIdempotency() optionIdempotency {
+	return optionIdempotency{
+		header:      "Idempotency-Key",
+		keyPrefix:   "idempotency",
+		methods:     []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		lifetime:    24 * time.Hour,
+		lockTimeout: 30 * time.Second,
+	}
+}
+
+// NewIdempotency honors Idempotency-Key header for mutation requests.
+// The first response (status, headers and body) is stored into redis and replayed for the same key,
+// a duplicate request while the first one is still processed is rejected with 409 and
+// reusing the key with a different payload is rejected with 422. Cache client must implement SetNX and Expire
+func NewIdempotency(rdb *dbc.RedisDBc, options ...OptionIdempotency) fiber.Handler {
+	opt := defaultIdempotency()
+	for _, o := range options {
+		o(&opt)
+	}
+
+	lk, ok := rdb.DB.(locker)
+	if !ok {
+		panic(fmt.Errorf("idempotency: cache client %T does not implement SetNX and Expire", rdb.DB))
+	}
+
+	return func(c *fiber.Ctx) error {
+		if !containsMethod(opt.methods, c.Method()) {
+			return c.Next()
+		}
+
+		idempotencyKey := strings.TrimSpace(c.Get(opt.header))
+		if idempotencyKey == "" {
+			if opt.required {
+				return errorkit.Error(fmt.Errorf("missing header %s", opt.header), errorkit.BadRequest, http.StatusBadRequest)
+			}
+
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		key := opt.redisKey(c, idempotencyKey)
+		fingerprint := idempotencyFingerprint(c)
+
+		lock, _ := json.Marshal(idempotencyRecord{State: idempotencyProcessing, Fingerprint: fingerprint})
+		acquired, err := lk.SetNX(ctx, key, lock, opt.lockTimeout).Result()
+		if err != nil {
+			logger.Log.Errorf(ctx, "idempotency: failed to acquire key %s: %s", key, err)
+			return errorkit.Error(err, errorkit.ServiceUnavailable, http.StatusServiceUnavailable)
+		}
+
+		// another request already hold the key, replay or reject it
+		if !acquired {
+			return replayIdempotency(c, rdb, key, fingerprint)
+		}
+
+		// keep the key while the handler runs longer than lock timeout
+		stop, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			keepLock(ctx, lk, key, opt.lockTimeout, stop)
+		}()
+
+		err = c.Next()
+		close(stop)
+		<-stopped
+
+		// key must be stored or released even when client is gone
+		writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyWriteTimeout)
+		defer cancel()
+
+		if err != nil {
+			// handler failed before writing response, release the key so client can retry
+			rdb.DB.Del(writeCtx, key)
+			return err
+		}
+
+		sc := c.Response().StatusCode()
+		if sc >= http.StatusInternalServerError {
+			rdb.DB.Del(writeCtx, key)
+			return nil
+		}
+
+		record := idempotencyRecord{
+			State:       idempotencyCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  sc,
+			Headers:     make(map[string][]string),
+			Body:        append([]byte(nil), c.Response().Body()...),
+		}
+		c.Response().Header.VisitAll(func(k, v []byte) {
+			switch strings.ToLower(string(k)) {
+			case "date", "content-length", "connection", "transfer-encoding":
+				return
+			}
+			// header with multiple values, e.g. Set-Cookie, is visited once per value
+			record.Headers[string(k)] = append(record.Headers[string(k)], string(v))
+		})
+
+		buf, _ := json.Marshal(record)
+		if err = rdb.DB.Set(writeCtx, key, buf, opt.lifetime).Err(); err != nil {
+			logger.Log.Errorf(ctx, "idempotency: failed to store response %s: %s", key, err)
+		}
+
+		return nil
+	}
+}
+
+// keepLock extend expiry of key every half of lock timeout until stop is closed
+func keepLock(ctx context.Context, lk locker, key string, lockTimeout time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(lockTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := lk.Expire(ctx, key, lockTimeout).Err(); err != nil {
+				logger.Log.Errorf(ctx, "idempotency: failed to extend key %s: %s", key, err)
+			}
+		}
+	}
+}
+
+func replayIdempotency(c *fiber.Ctx, rdb *dbc.RedisDBc, key, fingerprint string) error {
+	ctx := c.UserContext()
+
+	buf, err := rdb.DB.Get(ctx, key).Bytes()
+	if err != nil {
+		// key expired between SetNX and Get, ask client to retry
+		if errors.Is(err, goredis.Nil) {
+			return errorkit.Error(fmt.Errorf("idempotency key %s is released", key), errorkit.IdempotencyInProgress, http.StatusConflict)
+		}
+
+		return errorkit.Error(err, errorkit.ServiceUnavailable, http.StatusServiceUnavailable)
+	}
+
+	var record idempotencyRecord
+	if err = json.Unmarshal(buf, &record); err != nil {
+		return errorkit.Error(err, errorkit.InternalServer, http.StatusInternalServerError)
+	}
+
+	if record.Fingerprint != fingerprint {
+		return errorkit.Error(fmt.Errorf("idempotency key %s reused with different payload", key), errorkit.IdempotencyKeyReused, http.StatusUnprocessableEntity)
+	}
+
+	if record.State == idempotencyProcessing {
+		return errorkit.Error(fmt.Errorf("idempotency key %s is still processed", key), errorkit.IdempotencyInProgress, http.StatusConflict)
+	}
+
+	for k, values := range record.Headers {
+		c.Response().Header.Del(k)
+		for _, v := range values {
+			c.Response().Header.Add(k, v)
+		}
+	}
+	c.Set("Idempotent-Replayed", "true")
+	logger.Log.Printf(ctx, "idempotency: replay stored response %s", key)
+
+	return c.Status(record.StatusCode).Send(record.Body)
+}
+
+func (o optionIdempotency) redisKey(c *fiber.Ctx, idempotencyKey string) string {
+	if o.scope != nil {
+		return fmt.Sprintf("%s:%s:%s", o.keyPrefix, o.scope(c), idempotencyKey)
+	}
+
+	return fmt.Sprintf("%s:%s", o.keyPrefix, idempotencyKey)
+}
+
+// idempotencyFingerprint hash of method, url with query string and body to detect key reuse with a different payload
+func idempotencyFingerprint(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte(c.OriginalURL()))
+	h.Write(c.Body())
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetIdempotencyHeader set header name of idempotency key, default is Idempotency-Key
+func SetIdempotencyHeader(header string) OptionIdempotency {
+	return func(o *optionIdempotency) {
+		o.header = header
+	}
+}
+
+// SetIdempotencyKeyPrefix set prefix of redis key
+func SetIdempotencyKeyPrefix(prefix string) OptionIdempotency {
+	return func(o *optionIdempotency) {
+		o.keyPrefix = prefix
+	}
+}
+
+// SetIdempotencyMethods set http methods guarded by idempotency key
+func SetIdempotencyMethods(methods ...string) OptionIdempotency {
+	return func(o *optionIdempotency) {
+		o.methods = methods
+	}
+}
+
+// SetIdempotencyLifetime set how long stored response can be replayed
+func SetIdempotencyLifetime(lifetime time.Duration) OptionIdempotency {
+	return func(o *optionIdempotency) {
+		o.lifetime = lifetime
+	}
+}
+
+// SetIdempotencyLockTimeout set how long the key is held when the process handling the request die,
+// the key is extended every half of lock timeout while the handler runs
+func SetIdempotencyLockTimeout(lockTimeout time.Duration) OptionIdempotency {
+	return func(o *optionIdempotency) {
+		o.lockTimeout = lockTimeout
+	}
+}
+
+// SetIdempotencyRequired reject mutation request without idempotency key
+func SetIdempotencyRequired(required bool) OptionIdempotency {
+	return func(o *optionIdempotency) {
+		o.required = required
+	}
+}
+
+// SetIdempotencyScope set scope of idempotency key, e.g. user code, so the same key from different users won't collide
+func SetIdempotencyScope(scope func(c *fiber.Ctx) string) OptionIdempotency {
+	return func(o *optionIdempotency) {
+		o.scope = scope
+	}
+}
@@ -0,0 +1,303 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/vizucode/gokit/adapter/dbc"
+	"github.com/vizucode/gokit/utils/errorkit"
+)
+
+// memoryCache in-memory cache client with expiry
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string]string
+	expiry map[string]time.Time
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: map[string]string{}, expiry: map[string]time.Time{}}
+}
+
+// load value of key, must be called with lock held
+func (m *memoryCache) load(key string) (string, bool) {
+	if exp, ok := m.expiry[key]; ok && time.Now().After(exp) {
+		delete(m.values, key)
+		delete(m.expiry, key)
+	}
+
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func (m *memoryCache) store(key string, value interface{}, expiration time.Duration) {
+	switch v := value.(type) {
+	case []byte:
+		m.values[key] = string(v)
+	case string:
+		m.values[key] = v
+	default:
+		m.values[key] = fmt.Sprint(v)
+	}
+
+	delete(m.expiry, key)
+	if expiration > 0 {
+		m.expiry[key] = time.Now().Add(expiration)
+	}
+}
+
+func (m *memoryCache) Get(_ context.Context, key string) *goredis.StringCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.load(key)
+	if !ok {
+		return goredis.NewStringResult("", goredis.Nil)
+	}
+
+	return goredis.NewStringResult(v, nil)
+}
+
+func (m *memoryCache) Set(_ context.Context, key string, value interface{}, expiration time.Duration) *goredis.StatusCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store(key, value, expiration)
+	return goredis.NewStatusResult("OK", nil)
+}
+
+func (m *memoryCache) SetNX(_ context.Context, key string, value interface{}, expiration time.Duration) *goredis.BoolCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.load(key); ok {
+		return goredis.NewBoolResult(false, nil)
+	}
+
+	m.store(key, value, expiration)
+	return goredis.NewBoolResult(true, nil)
+}
+
+func (m *memoryCache) Expire(_ context.Context, key string, expiration time.Duration) *goredis.BoolCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.load(key); !ok {
+		return goredis.NewBoolResult(false, nil)
+	}
+
+	m.expiry[key] = time.Now().Add(expiration)
+	return goredis.NewBoolResult(true, nil)
+}
+
+func (m *memoryCache) Del(_ context.Context, keys ...string) *goredis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.values, key)
+		delete(m.expiry, key)
+	}
+
+	return goredis.NewIntResult(int64(len(keys)), nil)
+}
+
+func (m *memoryCache) Keys(context.Context, string) *goredis.StringSliceCmd {
+	return goredis.NewStringSliceResult(nil, nil)
+}
+
+// Scan match prefix pattern in a single iteration
+func (m *memoryCache) Scan(_ context.Context, _ uint64, match string, _ int64) *goredis.ScanCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keys []string
+	for key := range m.values {
+		if _, ok := m.load(key); ok && strings.HasPrefix(key, strings.TrimSuffix(match, "*")) {
+			keys = append(keys, key)
+		}
+	}
+
+	return goredis.NewScanCmdResult(keys, 0, nil)
+}
+
+// plainCache cache client without SetNX and Expire
+type plainCache struct {
+	dbc.CacheClient
+}
+
+func idempotentRequest(app *fiber.App, body string) (*http.Response, error) {
+	req := httptest.NewRequest(fiber.MethodPost, "/order", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", "key-1")
+
+	return app.Test(req, -1)
+}
+
+func TestIdempotencySlowHandler(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		sc, envelope := errorkit.ToEnvelope(err)
+		return c.Status(sc).JSON(envelope)
+	}})
+	app.Use(NewIdempotency(&dbc.RedisDBc{DB: newMemoryCache()}, SetIdempotencyLockTimeout(40*time.Millisecond)))
+	app.Post("/order", func(c *fiber.Ctx) error {
+		if calls.Add(1) == 1 {
+			<-release
+		}
+		return c.Status(http.StatusCreated).SendString("created")
+	})
+
+	first := make(chan *http.Response, 1)
+	go func() {
+		res, _ := idempotentRequest(app, "{}")
+		first <- res
+	}()
+
+	// the handler run longer than lock timeout, duplicate request must not take over the key
+	time.Sleep(150 * time.Millisecond)
+	res, err := idempotentRequest(app, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusConflict {
+		t.Fatalf("duplicate request status %d, want %d", res.StatusCode, http.StatusConflict)
+	}
+
+	close(release)
+	if res = <-first; res == nil || res.StatusCode != http.StatusCreated {
+		t.Fatalf("first request response %v", res)
+	}
+
+	res, err = idempotentRequest(app, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusCreated || res.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("stored response is not replayed, status %d", res.StatusCode)
+	}
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler is called %d times", calls.Load())
+	}
+}
+
+func TestIdempotencyRequireLocker(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("cache client without SetNX is accepted")
+		}
+	}()
+
+	NewIdempotency(&dbc.RedisDBc{DB: plainCache{}})
+}
+
+// detachedCache fail Del of cancelled context as redis client does
+type detachedCache struct {
+	*memoryCache
+}
+
+func (d detachedCache) Del(ctx context.Context, keys ...string) *goredis.IntCmd {
+	if err := ctx.Err(); err != nil {
+		return goredis.NewIntResult(0, err)
+	}
+
+	return d.memoryCache.Del(ctx, keys...)
+}
+
+func TestIdempotencyReplayHeaders(t *testing.T) {
+	var calls atomic.Int32
+
+	app := fiber.New()
+	app.Use(NewIdempotency(&dbc.RedisDBc{DB: newMemoryCache()}))
+	app.Post("/order", func(c *fiber.Ctx) error {
+		calls.Add(1)
+		c.Cookie(&fiber.Cookie{Name: "session", Value: "a"})
+		c.Cookie(&fiber.Cookie{Name: "theme", Value: "dark"})
+		return c.Status(http.StatusCreated).SendString("created")
+	})
+
+	for i := 0; i < 2; i++ {
+		res, err := idempotentRequest(app, "{}")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cookies := res.Header.Values(fiber.HeaderSetCookie); len(cookies) != 2 {
+			t.Fatalf("request %d got cookies %v", i, cookies)
+		}
+	}
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler is called %d times", calls.Load())
+	}
+}
+
+func TestIdempotencyQueryFingerprint(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: func(c *fiber.Ctx, err error) error {
+		sc, envelope := errorkit.ToEnvelope(err)
+		return c.Status(sc).JSON(envelope)
+	}})
+	app.Use(NewIdempotency(&dbc.RedisDBc{DB: newMemoryCache()}))
+	app.Post("/order", func(c *fiber.Ctx) error {
+		return c.Status(http.StatusCreated).SendString(c.Query("qty"))
+	})
+
+	for _, tt := range []struct {
+		qty        string
+		statusCode int
+	}{{"1", http.StatusCreated}, {"2", http.StatusUnprocessableEntity}} {
+		req := httptest.NewRequest(fiber.MethodPost, "/order?qty="+tt.qty, strings.NewReader("{}"))
+		req.Header.Set("Idempotency-Key", "key-1")
+
+		res, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.statusCode {
+			t.Fatalf("qty %s status %d, want %d", tt.qty, res.StatusCode, tt.statusCode)
+		}
+	}
+}
+
+func TestIdempotencyReleaseCancelled(t *testing.T) {
+	var calls atomic.Int32
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		// client is gone while the handler runs
+		ctx, cancel := context.WithCancel(c.UserContext())
+		cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Use(NewIdempotency(&dbc.RedisDBc{DB: detachedCache{newMemoryCache()}}))
+	app.Post("/order", func(c *fiber.Ctx) error {
+		calls.Add(1)
+		return c.SendStatus(http.StatusInternalServerError)
+	})
+
+	for i := 0; i < 2; i++ {
+		res, err := idempotentRequest(app, "{}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("request %d status %d", i, res.StatusCode)
+		}
+	}
+
+	if calls.Load() != 2 {
+		t.Fatalf("key is not released after failed request, handler is called %d times", calls.Load())
+	}
+}
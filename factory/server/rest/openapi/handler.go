@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<title>%s</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: '%s', dom_id: '#swagger-ui' });
+		};
+	</script>
+</body>
+</html>`
+
+// SpecHandler serve OpenAPI document as json
+func SpecHandler(doc *Document) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(doc)
+	}
+}
+
+// SwaggerUIHandler serve swagger ui page which load the document from specURL
+func SwaggerUIHandler(title, specURL string) fiber.Handler {
+	page := fmt.Sprintf(swaggerUI, title, specURL)
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(page)
+	}
+}
@@ -0,0 +1,238 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const version = "3.0.3"
+
+var (
+	mu         sync.RWMutex
+	operations = make(map[string]Operation)
+)
+
+// Document OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                               `json:"openapi"`
+	Info       Info                                 `json:"info"`
+	Servers    []Server                             `json:"servers,omitempty"`
+	Paths      map[string]map[string]*PathOperation `json:"paths"`
+	Components Components                           `json:"components"`
+}
+
+// Info general information of api
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server url where api served
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme authentication of api, e.g. bearer token
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// PathOperation single api operation on a path
+type PathOperation struct {
+	OperationId string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody payload of request
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response payload of response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType schema by content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Operation optional metadata of registered route
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	// Request sample of request body type, e.g. CreateUserRequest{}
+	Request interface{}
+	// Responses sample of response body type by http status code
+	Responses map[int]interface{}
+	// Queries query parameters name
+	Queries []string
+	// Security name of security schemes, e.g. bearerAuth
+	Security []string
+}
+
+// Describe register metadata of route, path is the same path registered into fiber, e.g. /v1/users/:id
+func Describe(method, path string, op Operation) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	operations[operationKey(method, path)] = op
+}
+
+// Generate OpenAPI document from fiber route table combined with metadata registered by Describe
+func Generate(routes []fiber.Route, opts ...OptionFunc) *Document {
+	opt := defaultOption()
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	doc := &Document{
+		OpenAPI: version,
+		Info:    opt.info,
+		Servers: opt.servers,
+		Paths:   make(map[string]map[string]*PathOperation),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: opt.securitySchemes,
+		},
+	}
+	sg := newSchemaGenerator(doc.Components.Schemas)
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Path < routes[j].Path
+	})
+
+	for _, route := range routes {
+		if strings.EqualFold(route.Method, http.MethodHead) || opt.isExcluded(route.Path) {
+			continue
+		}
+
+		path, params := convertPath(route.Path)
+		if _, ok := doc.Paths[path]; !ok {
+			doc.Paths[path] = make(map[string]*PathOperation)
+		}
+
+		op := &PathOperation{
+			OperationId: fmt.Sprintf("%s %s", route.Method, route.Path),
+			Parameters:  params,
+			Responses:   make(map[string]Response),
+		}
+
+		if meta, ok := operations[operationKey(route.Method, route.Path)]; ok {
+			op.Summary = meta.Summary
+			op.Description = meta.Description
+			op.Tags = meta.Tags
+			op.Deprecated = meta.Deprecated
+
+			for _, q := range meta.Queries {
+				op.Parameters = append(op.Parameters, Parameter{Name: q, In: "query", Schema: &Schema{Type: "string"}})
+			}
+
+			if meta.Request != nil {
+				op.RequestBody = &RequestBody{
+					Required: true,
+					Content:  map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: sg.generate(meta.Request)}},
+				}
+			}
+
+			for sc, body := range meta.Responses {
+				resp := Response{Description: http.StatusText(sc)}
+				if body != nil {
+					resp.Content = map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: sg.generate(body)}}
+				}
+				op.Responses[strconv.Itoa(sc)] = resp
+			}
+
+			for _, sec := range meta.Security {
+				op.Security = append(op.Security, map[string][]string{sec: {}})
+			}
+		}
+
+		if len(op.Responses) < 1 {
+			op.Responses["default"] = Response{Description: "response"}
+		}
+
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	return doc
+}
+
+// convertPath convert fiber path params (:id, :id?, :id<int>, *, +) into OpenAPI path params ({id}),
+// wildcards are numbered from one
+func convertPath(path string) (string, []Parameter) {
+	var (
+		params    []Parameter
+		wildcards int
+	)
+
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			// OpenAPI requires every path parameter, optional fiber param (:id?) is documented as required
+			name := strings.TrimPrefix(seg, ":")
+			if j := strings.IndexByte(name, '<'); j >= 0 {
+				name = name[:j]
+			}
+			name = strings.TrimSuffix(name, "?")
+
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		case seg == "*" || seg == "+":
+			wildcards++
+			name := "wildcard" + strconv.Itoa(wildcards)
+
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func operationKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
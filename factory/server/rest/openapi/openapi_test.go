@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	htmltemplate "html/template"
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type user struct {
+	Id       int     `json:"id"`
+	Name     string  `json:"name,omitempty"`
+	Children []*user `json:"children"`
+}
+
+type page[T any] struct {
+	Items []T `json:"items"`
+}
+
+func TestConvertPath(t *testing.T) {
+	path, params := convertPath("/v1/users/:id/orders/:orderId?")
+
+	if path != "/v1/users/{id}/orders/{orderId}" {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	if len(params) != 2 || params[0].Name != "id" || params[1].Name != "orderId" {
+		t.Errorf("unexpected params %+v", params)
+	}
+}
+
+func TestConvertPathConstraint(t *testing.T) {
+	path, params := convertPath("/v1/files/:id<int>/:name<minLen(2)>?/*/+")
+
+	if path != "/v1/files/{id}/{name}/{wildcard1}/{wildcard2}" {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	if len(params) != 4 || params[0].Name != "id" || params[1].Name != "name" || params[2].Name != "wildcard1" || params[3].Name != "wildcard2" {
+		t.Errorf("unexpected params %+v", params)
+	}
+}
+
+func TestComponentName(t *testing.T) {
+	g := newSchemaGenerator(map[string]*Schema{})
+	valid := regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+	text := g.componentName(reflect.TypeOf(texttemplate.Template{}))
+	html := g.componentName(reflect.TypeOf(htmltemplate.Template{}))
+	if text != "template.Template" || html == text {
+		t.Errorf("same named types collide, got %s and %s", text, html)
+	}
+
+	if !valid.MatchString(html) {
+		t.Errorf("invalid component name %s", html)
+	}
+
+	if name := g.componentName(reflect.TypeOf(page[user]{})); !valid.MatchString(name) {
+		t.Errorf("invalid component name of generic type %s", name)
+	}
+
+	if name := g.componentName(reflect.TypeOf(texttemplate.Template{})); name != text {
+		t.Errorf("name of the same type changed from %s to %s", text, name)
+	}
+}
+
+func TestIntegerFormat(t *testing.T) {
+	g := newSchemaGenerator(map[string]*Schema{})
+
+	for v, format := range map[interface{}]string{int8(0): "int32", uint16(0): "int32", uint(0): "int64", uint32(0): "int64", int(0): "int64"} {
+		if s := g.schemaOf(reflect.TypeOf(v)); s.Format != format {
+			t.Errorf("%T format %s, want %s", v, s.Format, format)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	app := fiber.New()
+	v1 := app.Group("/v1")
+	v1.Get("/users/:id", func(c *fiber.Ctx) error { return nil })
+	v1.Post("/users", func(c *fiber.Ctx) error { return nil })
+
+	Describe(http.MethodPost, "/v1/users", Operation{
+		Tags:      []string{"user"},
+		Request:   user{},
+		Responses: map[int]interface{}{http.StatusCreated: user{}},
+		Security:  []string{"bearerAuth"},
+	})
+
+	doc := Generate(app.GetRoutes(true), SetBearerAuth("bearerAuth"))
+
+	if _, ok := doc.Paths["/v1/users/{id}"]["get"]; !ok {
+		t.Error("missing GET /v1/users/{id}")
+	}
+
+	post, ok := doc.Paths["/v1/users"]["post"]
+	if !ok {
+		t.Fatal("missing POST /v1/users")
+	}
+
+	if post.RequestBody == nil || post.RequestBody.Content[fiber.MIMEApplicationJSON].Schema.Ref != "#/components/schemas/openapi.user" {
+		t.Errorf("unexpected request body %+v", post.RequestBody)
+	}
+
+	if _, ok := post.Responses["201"]; !ok {
+		t.Error("missing response 201")
+	}
+
+	schema, ok := doc.Components.Schemas["openapi.user"]
+	if !ok {
+		t.Fatal("missing schema openapi.user")
+	}
+
+	if schema.Properties["children"].Items.Ref != "#/components/schemas/openapi.user" {
+		t.Error("recursive type should refer to its own schema")
+	}
+}
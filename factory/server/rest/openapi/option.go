@@ -0,0 +1,80 @@
+package openapi
+
+import "strings"
+
+// OptionFunc setter openapi document options
+type OptionFunc func(*option)
+
+type option struct {
+	info            Info
+	servers         []Server
+	securitySchemes map[string]SecurityScheme
+	excludes        []string
+}
+
+func defaultOption() option {
+	return option{
+		info: Info{
+			Title:   "API Documentation",
+			Version: "1.0.0",
+		},
+		securitySchemes: make(map[string]SecurityScheme),
+	}
+}
+
+func (o option) isExcluded(path string) bool {
+	for _, prefix := range o.excludes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetTitle set title of api document
+func SetTitle(title string) OptionFunc {
+	return func(o *option) {
+		o.info.Title = title
+	}
+}
+
+// SetVersion set version of api document
+func SetVersion(version string) OptionFunc {
+	return func(o *option) {
+		o.info.Version = version
+	}
+}
+
+// SetDescription set description of api document
+func SetDescription(description string) OptionFunc {
+	return func(o *option) {
+		o.info.Description = description
+	}
+}
+
+// SetServers set urls where api served
+func SetServers(servers ...Server) OptionFunc {
+	return func(o *option) {
+		o.servers = servers
+	}
+}
+
+// SetSecurityScheme add security scheme, use the name on Operation.Security
+func SetSecurityScheme(name string, scheme SecurityScheme) OptionFunc {
+	return func(o *option) {
+		o.securitySchemes[name] = scheme
+	}
+}
+
+// SetBearerAuth add bearer token security scheme
+func SetBearerAuth(name string) OptionFunc {
+	return SetSecurityScheme(name, SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"})
+}
+
+// SetExcludePaths exclude routes with the path prefixes from document
+func SetExcludePaths(prefixes ...string) OptionFunc {
+	return func(o *option) {
+		o.excludes = append(o.excludes, prefixes...)
+	}
+}
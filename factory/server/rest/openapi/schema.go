@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schema JSON schema of request or response
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	// invalidName character not allowed in component name
+	invalidName = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+)
+
+// schemaGenerator generate schema from go type, named struct stored into components
+type schemaGenerator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	types      map[string]reflect.Type
+}
+
+func newSchemaGenerator(components map[string]*Schema) *schemaGenerator {
+	return &schemaGenerator{
+		components: components,
+		names:      make(map[reflect.Type]string),
+		types:      make(map[string]reflect.Type),
+	}
+}
+
+func (g *schemaGenerator) generate(v interface{}) *Schema {
+	return g.schemaOf(reflect.TypeOf(v))
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		s := g.schemaOf(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		name := g.componentName(t)
+		if _, ok := g.components[name]; !ok {
+			// reserve the name first to handle recursive type
+			g.components[name] = &Schema{}
+			*g.components[name] = *g.structSchema(t)
+		}
+
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+// componentName name of named type qualified by its package, e.g. model.User. Full package path is used when
+// another package has the same name. Character not allowed in component name, e.g. of generic type, is replaced
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if t.PkgPath() != "" {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	name = strings.Trim(invalidName.ReplaceAllString(name, "_"), "_")
+
+	if other, ok := g.types[name]; ok && other != t {
+		name = strings.Trim(invalidName.ReplaceAllString(t.PkgPath()+"."+t.Name(), "_"), "_")
+	}
+
+	g.names[t], g.types[name] = name, t
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		omitempty := false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, p := range parts[1:] {
+				if p == "omitempty" {
+					omitempty = true
+				}
+			}
+		}
+
+		// embedded struct without json name is flatten by encoding/json
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			embedded := g.structSchema(field.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+
+		s.Properties[name] = g.schemaOf(field.Type)
+		if !omitempty && field.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s
+}
@@ -1,11 +1,20 @@
 package rest
 
 import (
+	"crypto/tls"
 	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
+	grpcserver "github.com/vizucode/gokit/factory/server/grpc"
+	"github.com/vizucode/gokit/factory/server/rest/gateway"
+	"github.com/vizucode/gokit/factory/server/rest/grpcweb"
+	"github.com/vizucode/gokit/factory/server/rest/openapi"
+	"github.com/vizucode/gokit/factory/server/rest/realtime"
 	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/utils/certificate"
+	"github.com/vizucode/gokit/utils/env"
 )
 
 // OptionFunc setter rest options
@@ -19,15 +28,59 @@ type option struct {
 	engineOption func(app *fiber.App)
 	log          *logrus.Logger
 
-	// it's recomended to set error handling, default is fiber.DefaultErrorHandler
+	// it's recomended to set error handling, default is fiber.DefaultErrorHandler.
+	// Use ErrorHandler to render errorkit errors with errorkit.Envelope
 	errorHandler fiber.ErrorHandler
+
+	// openAPIPath path to serve swagger ui, openapi document served at {openAPIPath}/openapi.json
+	openAPIPath    string
+	openAPIOptions []openapi.OptionFunc
+
+	// tlsConfig serve https, certificate files are reloaded on change when set with SetTLS
+	tlsConfig       *tls.Config
+	tlsCertFile     string
+	tlsKeyFile      string
+	tlsCertificates []certificate.OptionFunc
+
+	// fiber server tuning, zero value use fiber default
+	bodyLimit      int
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+	idleTimeout    time.Duration
+	prefork        bool
+	proxyHeader    string
+	trustedProxies []string
+
+	// requestTimeout deadline of request context for all routes, zero only honor inbound budget
+	requestTimeout time.Duration
+
+	// shutdownDelay wait before shutdown so load balancer notice readiness failing
+	shutdownDelay time.Duration
+
+	// realtimeOptions options of websocket and server-sent events hub
+	realtimeOptions []realtime.OptionFunc
+
+	// gateway expose grpc services with grpc-gateway when grpc handler implement abstract.GatewayHandler
+	gateway        bool
+	gatewayOptions []gateway.OptionFunc
+	// grpcWeb expose grpc services to browser with grpc-web and connect protocol
+	grpcWeb        bool
+	grpcWebOptions []grpcweb.OptionFunc
+	// grpcServer grpc server of this service proxied by gateway and grpc-web
+	grpcServer grpcserver.Server
+
+	// global middlewares registered before and after http logging
+	beforeMiddlewares []fiber.Handler
+	afterMiddlewares  []fiber.Handler
 }
 
 // defaultOption default options for rest
 func defaultOption() option {
 	return option{
-		httpPort: "8080",
-		log:      logger.Logrus(),
+		httpPort:       "8080",
+		log:            logger.Logrus(),
+		shutdownDelay:  env.GetDuration("HTTP_SHUTDOWN_DELAY", 0),
+		requestTimeout: env.GetDuration("HTTP_REQUEST_TIMEOUT", 0),
 		cors: func(c *fiber.Ctx) error {
 			return c.Next()
 		},
@@ -49,7 +102,7 @@ func SetCors(cors fiber.Handler) OptionFunc {
 	}
 }
 
-// SetEngineOption set rest engine
+// SetEngineOption set rest engine, applied after fiber app created and before any route registered
 func SetEngineOption(app func(*fiber.App)) OptionFunc {
 	return func(o *option) {
 		o.engineOption = app
@@ -67,3 +120,140 @@ func SetErrorHandler(errorHandler fiber.ErrorHandler) OptionFunc {
 		o.errorHandler = errorHandler
 	}
 }
+
+// SetOpenAPI serve OpenAPI document generated from registered routes and swagger ui at path, e.g. /docs
+func SetOpenAPI(path string, opts ...openapi.OptionFunc) OptionFunc {
+	return func(o *option) {
+		o.openAPIPath = path
+		o.openAPIOptions = opts
+	}
+}
+
+// SetTLS serve https with certificate and key files, the files are reloaded when changed on disk.
+// Use certificate.SetClientCA to verify client certificate (mutual tls)
+func SetTLS(certFile, keyFile string, opts ...certificate.OptionFunc) OptionFunc {
+	return func(o *option) {
+		o.tlsCertFile = certFile
+		o.tlsKeyFile = keyFile
+		o.tlsCertificates = opts
+	}
+}
+
+// SetTLSConfig serve https with static tls configuration
+func SetTLSConfig(tlsConfig *tls.Config) OptionFunc {
+	return func(o *option) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// SetBodyLimit set maximum allowed size of request body in bytes, default is 4MB
+func SetBodyLimit(bodyLimit int) OptionFunc {
+	return func(o *option) {
+		o.bodyLimit = bodyLimit
+	}
+}
+
+// SetReadTimeout set amount of time allowed to read the full request including body
+func SetReadTimeout(readTimeout time.Duration) OptionFunc {
+	return func(o *option) {
+		o.readTimeout = readTimeout
+	}
+}
+
+// SetWriteTimeout set maximum duration before timing out writes of the response
+func SetWriteTimeout(writeTimeout time.Duration) OptionFunc {
+	return func(o *option) {
+		o.writeTimeout = writeTimeout
+	}
+}
+
+// SetIdleTimeout set maximum amount of time to wait for the next request when keep-alive is enabled
+func SetIdleTimeout(idleTimeout time.Duration) OptionFunc {
+	return func(o *option) {
+		o.idleTimeout = idleTimeout
+	}
+}
+
+// SetPrefork spawn multiple processes listening on the same port, not supported with tls
+func SetPrefork(prefork bool) OptionFunc {
+	return func(o *option) {
+		o.prefork = prefork
+	}
+}
+
+// SetProxyHeader set header used as client ip, e.g. X-Forwarded-For, so c.IP() on logs is the real client
+func SetProxyHeader(proxyHeader string) OptionFunc {
+	return func(o *option) {
+		o.proxyHeader = proxyHeader
+	}
+}
+
+// SetTrustedProxies only trust proxy header from the given ip or cidr ranges
+func SetTrustedProxies(proxies ...string) OptionFunc {
+	return func(o *option) {
+		o.trustedProxies = proxies
+	}
+}
+
+// SetMiddlewareBeforeLogger add global middlewares executed before http logging, also executed before realtime handlers
+func SetMiddlewareBeforeLogger(handlers ...fiber.Handler) OptionFunc {
+	return func(o *option) {
+		o.beforeMiddlewares = append(o.beforeMiddlewares, handlers...)
+	}
+}
+
+// SetMiddlewareAfterLogger add global middlewares executed after http logging, logging context is available
+func SetMiddlewareAfterLogger(handlers ...fiber.Handler) OptionFunc {
+	return func(o *option) {
+		o.afterMiddlewares = append(o.afterMiddlewares, handlers...)
+	}
+}
+
+// SetShutdownDelay set pre-stop delay, readiness is failing during the delay before server stop accepting request.
+// Server shutting down several applications wait the longest delay once
+func SetShutdownDelay(shutdownDelay time.Duration) OptionFunc {
+	return func(o *option) {
+		o.shutdownDelay = shutdownDelay
+	}
+}
+
+// SetRequestTimeout set deadline of request context for all routes, use Timeout for a single route
+func SetRequestTimeout(requestTimeout time.Duration) OptionFunc {
+	return func(o *option) {
+		o.requestTimeout = requestTimeout
+	}
+}
+
+// SetRealtimeOptions set options of websocket and server-sent events hub.
+// Write timeout of server should be zero when serving server-sent events
+func SetRealtimeOptions(opts ...realtime.OptionFunc) OptionFunc {
+	return func(o *option) {
+		o.realtimeOptions = append(o.realtimeOptions, opts...)
+	}
+}
+
+// SetGateway expose grpc services with grpc-gateway, mounted in rest server or on its own listener
+// with gateway.SetListenAddr. Grpc handler must implement abstract.GatewayHandler
+func SetGateway(opts ...gateway.OptionFunc) OptionFunc {
+	return func(o *option) {
+		o.gateway = true
+		o.gatewayOptions = opts
+	}
+}
+
+// SetGRPCServer set grpc server of this service, gateway and grpc-web connect to its bound listener
+// unless target is set. Set by service when grpc handler is registered
+func SetGRPCServer(server grpcserver.Server) OptionFunc {
+	return func(o *option) {
+		o.grpcServer = server
+	}
+}
+
+// SetGRPCWeb expose grpc services to browser with grpc-web and connect protocol, mounted in rest server
+// or on its own listener with grpcweb.SetListenAddr. Server streaming need own listener to be sent per message
+func SetGRPCWeb(opts ...grpcweb.OptionFunc) OptionFunc {
+	return func(o *option) {
+		o.grpcWeb = true
+		o.grpcWebOptions = opts
+	}
+}
@@ -0,0 +1,168 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/abstract"
+	"github.com/vizucode/gokit/utils/env"
+)
+
+const (
+	transportWebSocket = "websocket"
+	transportSSE       = "sse"
+)
+
+// OptionFunc setter hub options
+type OptionFunc func(*option)
+
+type option struct {
+	// heartbeat interval of websocket ping and sse comment
+	heartbeat time.Duration
+	// writeTimeout maximum duration to write a message
+	writeTimeout time.Duration
+	// origins allowed origin of websocket, allow all when empty
+	origins []string
+	// middlewares executed before every realtime handler
+	middlewares []fiber.Handler
+}
+
+func defaultOption() option {
+	return option{
+		heartbeat:    env.GetDuration("REALTIME_HEARTBEAT_INTERVAL", 30*time.Second),
+		writeTimeout: env.GetDuration("REALTIME_WRITE_TIMEOUT", 10*time.Second),
+	}
+}
+
+// Hub implement abstract.RealtimeRouter and keep track of realtime connections, so they are closed gracefully on shutdown
+type Hub struct {
+	router  fiber.Router
+	service string
+	opt     option
+
+	mu          sync.Mutex
+	connections map[*session]struct{}
+	wg          sync.WaitGroup
+	closing     atomic.Bool
+}
+
+var (
+	_ abstract.RealtimeRouter = (*Hub)(nil)
+	_ abstract.WebSocketConn  = (*Conn)(nil)
+	_ abstract.SSEStream      = (*Stream)(nil)
+)
+
+// New create hub which register realtime handlers into router
+func New(router fiber.Router, service string, opts ...OptionFunc) *Hub {
+	h := &Hub{
+		router:      router,
+		service:     service,
+		opt:         defaultOption(),
+		connections: make(map[*session]struct{}),
+	}
+
+	for _, o := range opts {
+		o(&h.opt)
+	}
+
+	return h
+}
+
+// Len number of open connections
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.connections)
+}
+
+// Shutdown reject new connection and ask open connections to close,
+// connections still open when context is done are closed forcefully
+func (h *Hub) Shutdown(ctx context.Context) {
+	h.closing.Store(true)
+
+	h.mu.Lock()
+	for s := range h.connections {
+		s.shutdown()
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		h.mu.Lock()
+		for s := range h.connections {
+			s.forceClose()
+		}
+		h.mu.Unlock()
+	}
+}
+
+// handle register handler on path, hub middlewares are executed before route middlewares
+func (h *Hub) handle(path string, middlewares []fiber.Handler, handler fiber.Handler) {
+	handlers := make([]fiber.Handler, 0, len(h.opt.middlewares)+len(middlewares)+1)
+	handlers = append(handlers, h.opt.middlewares...)
+	handlers = append(handlers, middlewares...)
+
+	h.router.Get(path, append(handlers, handler)...)
+}
+
+func (h *Hub) track(s *session) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closing.Load() {
+		return false
+	}
+
+	h.connections[s] = struct{}{}
+	h.wg.Add(1)
+	return true
+}
+
+func (h *Hub) untrack(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.connections[s]; ok {
+		delete(h.connections, s)
+		h.wg.Done()
+	}
+}
+
+// SetHeartbeatInterval set interval of websocket ping and sse heartbeat comment
+func SetHeartbeatInterval(heartbeat time.Duration) OptionFunc {
+	return func(o *option) {
+		o.heartbeat = heartbeat
+	}
+}
+
+// SetWriteTimeout set maximum duration to write a message
+func SetWriteTimeout(writeTimeout time.Duration) OptionFunc {
+	return func(o *option) {
+		o.writeTimeout = writeTimeout
+	}
+}
+
+// SetAllowedOrigins set allowed origin of websocket connection
+func SetAllowedOrigins(origins ...string) OptionFunc {
+	return func(o *option) {
+		o.origins = origins
+	}
+}
+
+// SetMiddlewares add middlewares executed before every realtime handler, e.g. authentication
+func SetMiddlewares(handlers ...fiber.Handler) OptionFunc {
+	return func(o *option) {
+		o.middlewares = append(o.middlewares, handlers...)
+	}
+}
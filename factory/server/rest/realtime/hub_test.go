@@ -0,0 +1,227 @@
+package realtime
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	fastws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/abstract"
+)
+
+// serve hub on local listener, handlers are registered by register
+func serve(t *testing.T, register func(h *Hub), opts ...OptionFunc) (*Hub, string) {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	h := New(app, "test", opts...)
+	register(h)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.ShutdownWithTimeout(time.Second) })
+
+	return h, ln.Addr().String()
+}
+
+// waitLen wait until hub has n open connections
+func waitLen(t *testing.T, h *Hub, n int) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); h.Len() != n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("hub has %d connections, want %d", h.Len(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	var order []string
+	_, addr := serve(t, func(h *Hub) {
+		h.WebSocket("/echo/:room", func(conn abstract.WebSocketConn) error {
+			var in map[string]string
+			if err := conn.ReadJSON(&in); err != nil {
+				return err
+			}
+
+			in["room"] = conn.Params("room")
+			in["user"], _ = conn.Locals("user").(string)
+			return conn.WriteJSON(in)
+		}, func(c *fiber.Ctx) error {
+			order = append(order, "route")
+			c.Locals("user", "alice")
+			return c.Next()
+		})
+	}, SetMiddlewares(func(c *fiber.Ctx) error {
+		order = append(order, "hub")
+		return c.Next()
+	}))
+
+	ws, _, err := fastws.DefaultDialer.Dial("ws://"+addr+"/echo/lobby", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	if err = ws.WriteJSON(map[string]string{"text": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out map[string]string
+	if err = ws.ReadJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out["text"] != "hi" || out["room"] != "lobby" || out["user"] != "alice" {
+		t.Fatalf("unexpected echo %v", out)
+	}
+	if strings.Join(order, ",") != "hub,route" {
+		t.Fatalf("middlewares executed in order %v", order)
+	}
+
+	// handler returned, connection is closed normally
+	_, _, err = ws.ReadMessage()
+	if !fastws.IsCloseError(err, fastws.CloseNormalClosure) {
+		t.Fatalf("got %v, want normal closure", err)
+	}
+}
+
+func TestMiddlewareReject(t *testing.T) {
+	called := false
+	reject := func(*fiber.Ctx) error { return fiber.ErrUnauthorized }
+
+	_, addr := serve(t, func(h *Hub) {
+		h.WebSocket("/ws", func(abstract.WebSocketConn) error {
+			called = true
+			return nil
+		}, reject)
+		h.SSE("/sse", func(abstract.SSEStream) error {
+			called = true
+			return nil
+		}, reject)
+	})
+
+	_, resp, err := fastws.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("websocket upgraded, response %v, err %v", resp, err)
+	}
+
+	res, err := http.Get("http://" + addr + "/sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("sse status %d", res.StatusCode)
+	}
+
+	if called {
+		t.Fatal("handler is called after middleware rejected the request")
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	h, addr := serve(t, func(h *Hub) {
+		h.WebSocket("/ws", func(conn abstract.WebSocketConn) error {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return err
+				}
+			}
+		})
+		h.SSE("/sse", func(stream abstract.SSEStream) error {
+			<-stream.Context().Done()
+			return nil
+		})
+	})
+
+	ws, _, err := fastws.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	res, err := http.Get("http://" + addr + "/sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	waitLen(t, h, 2)
+
+	// client answer close frame of websocket while reading
+	closed := make(chan error, 1)
+	go func() {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				closed <- err
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	h.Shutdown(ctx)
+
+	if ctx.Err() != nil {
+		t.Fatal("connections are not closed gracefully")
+	}
+	if h.Len() != 0 {
+		t.Fatalf("hub has %d connections after shutdown", h.Len())
+	}
+
+	if err = <-closed; !fastws.IsCloseError(err, fastws.CloseGoingAway) {
+		t.Fatalf("got %v, want going away", err)
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	if !strings.Contains(string(body), "event: close\n") {
+		t.Fatalf("got %q, want close event", body)
+	}
+
+	// new connection is rejected
+	res, err = http.Get("http://" + addr + "/sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status %d after shutdown", res.StatusCode)
+	}
+}
+
+func TestShutdownForce(t *testing.T) {
+	h, addr := serve(t, func(h *Hub) {
+		h.WebSocket("/ws", func(conn abstract.WebSocketConn) error {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return err
+				}
+			}
+		})
+	})
+
+	// client never read, so close frame is not answered
+	ws, _, err := fastws.DefaultDialer.Dial("ws://"+addr+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	waitLen(t, h, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	h.Shutdown(ctx)
+
+	waitLen(t, h, 0)
+}
@@ -0,0 +1,133 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/tracer"
+	"github.com/vizucode/gokit/utils/monitoring"
+	"github.com/vizucode/gokit/utils/recovery"
+	"github.com/vizucode/gokit/utils/timezone"
+)
+
+// session logging and tracing context of a realtime connection
+type session struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	trace     tracer.Tracer
+	dl        *logger.DataLogger
+	transport string
+	path      string
+
+	received atomic.Int64
+	sent     atomic.Int64
+
+	// onShutdown ask client to close the connection, onForceClose close the connection immediately
+	onShutdown   func()
+	onForceClose func()
+}
+
+func (h *Hub) newSession(transport, path, requestId, ip, device string) *session {
+	if requestId == "" {
+		requestId = uuid.NewString()
+	}
+
+	s := &session{
+		transport: transport,
+		path:      path,
+		dl: &logger.DataLogger{
+			RequestId:     requestId,
+			Ip:            ip,
+			Device:        device,
+			Type:          logger.ServiceType(transport),
+			TimeStart:     time.Now().In(timezone.JakartaTz()),
+			Service:       h.service,
+			Endpoint:      path,
+			RequestMethod: http.MethodGet,
+		},
+	}
+
+	var ctx context.Context
+	s.trace, ctx = tracer.StartTraceWithContext(context.Background(), fmt.Sprintf("%s %s", transport, path))
+
+	// set logger into context with key LogKey
+	lock := new(logger.Locker)
+	ctx = context.WithValue(ctx, logger.LogKey, lock)
+	lock.Set(logger.RequestId, requestId)
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	s.trace.SetTag("request_id", requestId)
+	s.trace.SetTag("trace_id", tracer.GetTraceID(s.ctx))
+	monitoring.RealtimeConnection(transport, path, h.service, 1)
+
+	return s
+}
+
+// traceMessage trace every received or sent message
+func (s *session) traceMessage(direction string, data []byte) {
+	if direction == "receive" {
+		s.received.Add(1)
+	} else {
+		s.sent.Add(1)
+	}
+
+	trace, _ := tracer.StartTraceWithContext(s.ctx, fmt.Sprintf("%s:%s %s", s.transport, direction, s.path))
+	trace.SetTag("message.size", len(data))
+	if len(data) > 1000 {
+		trace.SetTag("message.body", "message too large")
+	} else {
+		trace.SetTag("message.body", data)
+	}
+	trace.Finish()
+
+	monitoring.RealtimeMessage(s.transport, s.path, direction, s.dl.Service)
+}
+
+// recover must be deferred by the connection handler
+func (s *session) recover(err *error) {
+	if re := recover(); re != nil {
+		p := recovery.New(s.transport, s.dl.Service, s.path, re)
+		p.Record(s.ctx, s.trace)
+		*err = p
+	}
+}
+
+// finish record logging of the whole connection
+func (s *session) finish(err error) {
+	s.cancel()
+
+	sc := http.StatusOK
+	if err != nil {
+		sc = http.StatusInternalServerError
+		s.trace.SetError(err)
+	}
+
+	s.trace.SetTag("message.received", s.received.Load())
+	s.trace.SetTag("message.sent", s.sent.Load())
+
+	logger.Response(s.ctx, sc, fmt.Sprintf("received %d messages, sent %d messages", s.received.Load(), s.sent.Load()), err)
+	s.dl.Finalize(s.ctx)
+	s.trace.Finish()
+
+	monitoring.RealtimeConnection(s.transport, s.path, s.dl.Service, -1)
+}
+
+func (s *session) shutdown() {
+	if s.onShutdown != nil {
+		s.onShutdown()
+	}
+	s.cancel()
+}
+
+func (s *session) forceClose() {
+	if s.onForceClose != nil {
+		s.onForceClose()
+	}
+	s.cancel()
+}
@@ -0,0 +1,86 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vizucode/gokit/logger"
+)
+
+func TestSessionRequestId(t *testing.T) {
+	h := New(nil, "test")
+
+	s := h.newSession(transportSSE, "/feed", "req-1", "127.0.0.1", "test")
+	if got := logger.GetRequestId(s.ctx); got != "req-1" {
+		t.Fatalf("request id %q, want req-1", got)
+	}
+	s.finish(nil)
+
+	s = h.newSession(transportSSE, "/feed", "", "127.0.0.1", "test")
+	if s.dl.RequestId == "" || logger.GetRequestId(s.ctx) != s.dl.RequestId {
+		t.Fatalf("request id is not generated, got %q", s.dl.RequestId)
+	}
+	s.finish(nil)
+
+	if s.ctx.Err() == nil {
+		t.Fatal("context is not done after finish")
+	}
+}
+
+func TestSessionMessages(t *testing.T) {
+	s := New(nil, "test").newSession(transportWebSocket, "/ws", "", "127.0.0.1", "test")
+	defer s.finish(nil)
+
+	s.traceMessage("receive", []byte("a"))
+	s.traceMessage("send", []byte("b"))
+	s.traceMessage("send", []byte("c"))
+
+	if s.received.Load() != 1 || s.sent.Load() != 2 {
+		t.Fatalf("received %d, sent %d", s.received.Load(), s.sent.Load())
+	}
+}
+
+func TestSessionRecover(t *testing.T) {
+	s := New(nil, "test").newSession(transportWebSocket, "/ws", "", "127.0.0.1", "test")
+
+	var err error
+	func() {
+		defer s.recover(&err)
+		panic(errors.New("boom"))
+	}()
+	s.finish(err)
+
+	if err == nil {
+		t.Fatal("panic is not converted into error")
+	}
+}
+
+func TestSessionShutdown(t *testing.T) {
+	h := New(nil, "test")
+	s := h.newSession(transportWebSocket, "/ws", "", "127.0.0.1", "test")
+
+	asked := false
+	s.onShutdown = func() { asked = true }
+
+	if !h.track(s) {
+		t.Fatal("session is rejected before shutdown")
+	}
+	go func() {
+		<-s.ctx.Done()
+		h.untrack(s)
+		s.finish(nil)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	h.Shutdown(ctx)
+
+	if !asked || h.Len() != 0 {
+		t.Fatalf("shutdown asked %v, open connections %d", asked, h.Len())
+	}
+	if h.track(h.newSession(transportWebSocket, "/ws", "", "127.0.0.1", "test")) {
+		t.Fatal("session is accepted after shutdown")
+	}
+}
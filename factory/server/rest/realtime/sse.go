@@ -0,0 +1,179 @@
+package realtime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/abstract"
+)
+
+// Stream server-sent events stream with logging and tracing context
+type Stream struct {
+	*session
+	w  *bufio.Writer
+	mu sync.Mutex
+
+	params  map[string]string
+	queries map[string]string
+	headers map[string]string
+}
+
+// SSE register server-sent events handler on path
+func (h *Hub) SSE(path string, handler abstract.SSEHandlerFunc, middlewares ...fiber.Handler) {
+	h.handle(path, middlewares, func(c *fiber.Ctx) error {
+		if h.closing.Load() {
+			return fiber.ErrServiceUnavailable
+		}
+
+		// copy request values, fiber context is released before the stream is written
+		stream := &Stream{
+			params:  make(map[string]string),
+			queries: c.Queries(),
+			headers: make(map[string]string),
+		}
+		for key, val := range c.AllParams() {
+			stream.params[key] = val
+		}
+		for key, val := range c.GetReqHeaders() {
+			if len(val) > 0 {
+				stream.headers[textproto.CanonicalMIMEHeaderKey(key)] = val[0]
+			}
+		}
+
+		requestId := c.Get("X-Request-Id")
+		ip, device := c.IP(), c.Get(fiber.HeaderUserAgent)
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			stream.session = h.newSession(transportSSE, path, requestId, ip, device)
+			stream.w = w
+			h.serveSSE(stream, handler)
+		})
+
+		return nil
+	})
+}
+
+func (h *Hub) serveSSE(stream *Stream, handler abstract.SSEHandlerFunc) {
+	stream.onShutdown = func() {
+		_ = stream.Send("close", []byte("server shutting down"))
+	}
+
+	if !h.track(stream.session) {
+		stream.onShutdown()
+		stream.finish(nil)
+		return
+	}
+	defer h.untrack(stream.session)
+
+	var err error
+	defer func() {
+		stream.finish(err)
+	}()
+	defer stream.recover(&err)
+
+	go stream.heartbeat(h.opt.heartbeat)
+
+	// response header is held until the first write, comment let client know the stream is open
+	_ = stream.write([]byte(": connected\n\n"))
+
+	err = handler(stream)
+}
+
+// heartbeat write comment periodically, the stream context is done when client is gone
+func (s *Stream) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.write([]byte(": heartbeat\n\n")); err != nil {
+				s.cancel()
+				return
+			}
+		}
+	}
+}
+
+// Context logging context of the stream, done when client is gone or server shutting down
+func (s *Stream) Context() context.Context {
+	return s.ctx
+}
+
+// Params get route params
+func (s *Stream) Params(key string) string {
+	return s.params[key]
+}
+
+// Query get query string
+func (s *Stream) Query(key string) string {
+	return s.queries[key]
+}
+
+// Headers get request header, key is case-insensitive
+func (s *Stream) Headers(key string) string {
+	return s.headers[textproto.CanonicalMIMEHeaderKey(key)]
+}
+
+// Send write event to client, empty event is sent as default message event. Event containing line break is
+// rejected since it would inject fields into the stream. Safe for concurrent use
+func (s *Stream) Send(event string, data []byte) error {
+	if strings.ContainsAny(event, "\r\n") {
+		return fmt.Errorf("sse event %q contains line break", event)
+	}
+
+	var buf bytes.Buffer
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+	// every line break of data (CRLF, CR or LF) starts a new data field
+	data = bytes.ReplaceAll(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), []byte("\r"), []byte("\n"))
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+
+	if err := s.write(buf.Bytes()); err != nil {
+		s.cancel()
+		return err
+	}
+
+	s.traceMessage("send", data)
+	return nil
+}
+
+// SendJSON write v as json data of event
+func (s *Stream) SendJSON(event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.Send(event, data)
+}
+
+func (s *Stream) write(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(p); err != nil {
+		return err
+	}
+
+	return s.w.Flush()
+}
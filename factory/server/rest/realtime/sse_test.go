@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/abstract"
+)
+
+func TestSSEEvent(t *testing.T) {
+	app := fiber.New()
+	h := New(app, "test")
+	h.SSE("/feed/:topic", func(stream abstract.SSEStream) error {
+		if err := stream.Send("", []byte("line 1\r\nline 2")); err != nil {
+			return err
+		}
+
+		if err := stream.Send("order\ndata: injected", nil); err == nil {
+			return errors.New("event with line break is sent")
+		}
+
+		return stream.SendJSON(stream.Params("topic"), map[string]string{
+			"filter": stream.Query("filter"),
+			"client": stream.Headers("x-client"),
+		})
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/feed/order?filter=new", nil)
+	req.Header.Set("X-Client", "web")
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get(fiber.HeaderContentType); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	want := ": connected\n\n" +
+		"data: line 1\ndata: line 2\n\n" +
+		"event: order\ndata: {\"client\":\"web\",\"filter\":\"new\"}\n\n"
+	if string(body) != want {
+		t.Fatalf("got %q, want %q", body, want)
+	}
+
+	if h.Len() != 0 {
+		t.Fatalf("stream is still tracked after handler returned")
+	}
+}
+
+func TestSSEPanic(t *testing.T) {
+	app := fiber.New()
+	h := New(app, "test")
+	h.SSE("/panic", func(stream abstract.SSEStream) error {
+		_ = stream.Send("", []byte("before"))
+		panic(errors.New("boom"))
+	})
+
+	res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/panic", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if string(body) != ": connected\n\ndata: before\n\n" {
+		t.Fatalf("got %q", body)
+	}
+
+	if h.Len() != 0 {
+		t.Fatalf("stream is still tracked after panic")
+	}
+}
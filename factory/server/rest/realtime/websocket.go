@@ -0,0 +1,184 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/abstract"
+)
+
+// Conn websocket connection with logging and tracing context
+type Conn struct {
+	*session
+	ws           *websocket.Conn
+	mu           sync.Mutex
+	writeTimeout time.Duration
+}
+
+// WebSocket register websocket handler on path, locals set by middlewares are available from Conn.Locals
+func (h *Hub) WebSocket(path string, handler abstract.WebSocketHandlerFunc, middlewares ...fiber.Handler) {
+	upgrade := websocket.New(func(ws *websocket.Conn) {
+		h.serveWebSocket(path, ws, handler)
+	}, websocket.Config{
+		Origins: h.opt.origins,
+		// panic is recovered by serveWebSocket
+		RecoverHandler: func(*websocket.Conn) {},
+	})
+
+	h.handle(path, middlewares, func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+
+		if h.closing.Load() {
+			return fiber.ErrServiceUnavailable
+		}
+
+		return upgrade(c)
+	})
+}
+
+func (h *Hub) serveWebSocket(path string, ws *websocket.Conn, handler abstract.WebSocketHandlerFunc) {
+	conn := &Conn{
+		session:      h.newSession(transportWebSocket, path, ws.Headers("X-Request-Id"), ws.IP(), ws.Headers("User-Agent")),
+		ws:           ws,
+		writeTimeout: h.opt.writeTimeout,
+	}
+	conn.onShutdown = func() {
+		_ = conn.CloseWithReason(websocket.CloseGoingAway, "server shutting down")
+	}
+	conn.onForceClose = func() {
+		// close of hijacked connection is left to fasthttp, expired deadline unblock the handler instead
+		_ = ws.SetReadDeadline(time.Now())
+		_ = ws.SetWriteDeadline(time.Now())
+		_ = ws.Close()
+	}
+
+	if !h.track(conn.session) {
+		_ = conn.CloseWithReason(websocket.CloseTryAgainLater, "server shutting down")
+		conn.finish(nil)
+		return
+	}
+	defer h.untrack(conn.session)
+
+	var err error
+	defer func() {
+		conn.finish(err)
+		_ = ws.Close()
+	}()
+	defer conn.recover(&err)
+
+	// the connection is considered dead when pong is not received within two heartbeats
+	_ = ws.SetReadDeadline(time.Now().Add(2 * h.opt.heartbeat))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(2 * h.opt.heartbeat))
+	})
+	go conn.heartbeat(h.opt.heartbeat)
+
+	err = handler(conn)
+	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+		err = nil
+	}
+
+	if err != nil {
+		_ = conn.CloseWithReason(websocket.CloseInternalServerErr, "")
+		return
+	}
+
+	_ = conn.CloseWithReason(websocket.CloseNormalClosure, "")
+}
+
+// heartbeat ping client periodically until the connection is closed
+func (c *Conn) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Context logging context of the connection, done when connection closed or server shutting down
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Params get route params captured on upgrade
+func (c *Conn) Params(key string, defaultValue ...string) string {
+	return c.ws.Params(key, defaultValue...)
+}
+
+// Query get query string captured on upgrade
+func (c *Conn) Query(key string, defaultValue ...string) string {
+	return c.ws.Query(key, defaultValue...)
+}
+
+// Headers get request header captured on upgrade
+func (c *Conn) Headers(key string, defaultValue ...string) string {
+	return c.ws.Headers(key, defaultValue...)
+}
+
+// Locals get locals set by previous middleware
+func (c *Conn) Locals(key string) interface{} {
+	return c.ws.Locals(key)
+}
+
+// ReadMessage read next message from client
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	messageType, data, err = c.ws.ReadMessage()
+	if err == nil {
+		c.traceMessage("receive", data)
+	}
+
+	return messageType, data, err
+}
+
+// ReadJSON read next message from client and decode it into v
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage write message to client, safe for concurrent use
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_ = c.ws.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	if err := c.ws.WriteMessage(messageType, data); err != nil {
+		return err
+	}
+
+	c.traceMessage("send", data)
+	return nil
+}
+
+// WriteJSON write v as json text message to client
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.WriteMessage(websocket.TextMessage, data)
+}
+
+// CloseWithReason send close frame to client
+func (c *Conn) CloseWithReason(code int, reason string) error {
+	return c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(c.writeTimeout))
+}
@@ -2,19 +2,27 @@ package rest
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/hellofresh/health-go/v4"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vizucode/gokit/abstract"
 	"github.com/vizucode/gokit/factory"
+	"github.com/vizucode/gokit/factory/server/rest/gateway"
+	"github.com/vizucode/gokit/factory/server/rest/grpcweb"
+	"github.com/vizucode/gokit/factory/server/rest/openapi"
+	"github.com/vizucode/gokit/factory/server/rest/realtime"
 	"github.com/vizucode/gokit/logger"
 	"github.com/vizucode/gokit/types"
+	"github.com/vizucode/gokit/utils/certificate"
 	"github.com/vizucode/gokit/utils/timezone"
 )
 
@@ -24,55 +32,160 @@ type rest struct {
 	service      factory.ServiceFactory
 	opt          option
 	tz           *time.Location
+	certificate  *certificate.Reloader
+	health       *health.Health
+	realtime     *realtime.Hub
+	gateway      *gateway.Gateway
+	grpcWeb      *grpcweb.Web
+	// draining flip readiness to failing while shutting down
+	draining atomic.Bool
+	// inFlight number of requests currently processed
+	inFlight atomic.Int64
 }
 
+// drainingCheck reserved name of health check failing while shutting down
+const drainingCheck = "gokit.rest.draining"
+
 // New creates new handler for rest server
 func New(svc factory.ServiceFactory, opts ...OptionFunc) factory.ApplicationFactory {
-	var (
-		tz = timezone.JakartaTz()
-
-		fiberConfig = fiber.Config{
-			AppName:           svc.Name(),
-			Prefork:           false,
-			ReduceMemoryUsage: true,
-		}
-	)
-
 	// init an instance rest handler
 	srv := &rest{
-		tz:           tz,
-		opt:          defaultOption(),
-		service:      svc,
-		serverEngine: fiber.New(fiberConfig),
+		tz:      timezone.JakartaTz(),
+		opt:     defaultOption(),
+		service: svc,
 	}
 
 	for _, o := range opts {
 		o(&srv.opt)
 	}
 
-	// set custom fiber error handling
-	fiberConfig.ErrorHandler = srv.opt.errorHandler
-	srv.serverEngine = fiber.New(fiberConfig)
+	// load certificate for https
+	if srv.opt.tlsCertFile != "" {
+		reloader, err := certificate.NewReloader(srv.opt.tlsCertFile, srv.opt.tlsKeyFile, srv.opt.tlsCertificates...)
+		if err != nil {
+			panic(fmt.Errorf("rest server: %s", err))
+		}
+
+		srv.certificate = reloader
+		srv.opt.tlsConfig = reloader.TLSConfig("http/1.1")
+	}
+
+	srv.serverEngine = fiber.New(fiber.Config{
+		AppName:                 svc.Name(),
+		Prefork:                 srv.opt.prefork,
+		ReduceMemoryUsage:       true,
+		BodyLimit:               srv.opt.bodyLimit,
+		ReadTimeout:             srv.opt.readTimeout,
+		WriteTimeout:            srv.opt.writeTimeout,
+		IdleTimeout:             srv.opt.idleTimeout,
+		ProxyHeader:             srv.opt.proxyHeader,
+		EnableTrustedProxyCheck: len(srv.opt.trustedProxies) > 0,
+		TrustedProxies:          srv.opt.trustedProxies,
+		// set custom fiber error handling
+		ErrorHandler: srv.opt.errorHandler,
+	})
 
+	// apply custom engine configuration, e.g. register hooks or mount sub app
+	if srv.opt.engineOption != nil {
+		srv.opt.engineOption(srv.serverEngine)
+	}
+
+	// count in-flight requests to report requests cut off on shutdown
+	srv.serverEngine.Use(srv.countInFlight)
 	// add cors middleware
 	srv.serverEngine.Use(srv.opt.cors)
-	// start handler for health-check
-	h, _ := health.New()
+	// start handler for health-check with dependency checks of service, unavailable while draining
+	var checks []health.Config
+	if hs, ok := svc.(factory.HealthCheckService); ok {
+		checks = hs.HealthChecks()
+	}
+	h, err := health.New(health.WithChecks(append([]health.Config{{
+		Name: drainingCheck,
+		Check: func(context.Context) error {
+			if srv.draining.Load() {
+				return fmt.Errorf("server is shutting down")
+			}
+			return nil
+		},
+	}}, checks...)...))
+	if err != nil {
+		panic(fmt.Errorf("rest server: %s", err))
+	}
+	srv.health = h
 	lg := srv.serverEngine.Group("/live")
-	lg.Get("/status", adaptor.HTTPHandler(h.Handler()))
+	lg.Get("/status", adaptor.HTTPHandler(srv.health.Handler()))
 	// metrics for prometheus
 	mg := srv.serverEngine.Group("/metrics")
 	mg.Get("", adaptor.HTTPHandler(promhttp.Handler()))
 
+	// websocket and server-sent events, registered before root path so long-lived
+	// connections are logged per connection instead of by http logging. Middlewares before
+	// logger are executed per route, middlewares after logger need http logging context so they are not
+	if rs, ok := svc.(factory.RealtimeService); ok && rs.RealtimeHandler() != nil {
+		opts := append([]realtime.OptionFunc{realtime.SetMiddlewares(srv.opt.beforeMiddlewares...)}, srv.opt.realtimeOptions...)
+		srv.realtime = realtime.New(srv.serverEngine, svc.Name(), opts...)
+		rs.RealtimeHandler().Realtime(srv.realtime)
+	}
+
 	// root path for http handler
 	rootPath := srv.serverEngine.Group("")
+	for _, m := range srv.opt.beforeMiddlewares {
+		rootPath.Use(m)
+	}
 	rootPath.Use(srv.restTraceLogger) // implement http logging
+	rootPath.Use(srv.requestDeadline) // implement request timeout and inbound deadline
+	for _, m := range srv.opt.afterMiddlewares {
+		rootPath.Use(m)
+	}
 
 	// apply handler to root path
 	if h := svc.RESTHandler(); h != nil {
 		h.Router(rootPath)
 	}
 
+	// serve openapi document, generated after all routes registered
+	if srv.opt.openAPIPath != "" {
+		opts := append([]openapi.OptionFunc{
+			openapi.SetTitle(svc.Name()),
+			openapi.SetExcludePaths("/live", "/metrics", srv.opt.openAPIPath),
+		}, srv.opt.openAPIOptions...)
+
+		doc := openapi.Generate(srv.serverEngine.GetRoutes(true), opts...)
+		srv.serverEngine.Get(srv.opt.openAPIPath+"/openapi.json", openapi.SpecHandler(doc))
+		srv.serverEngine.Get(srv.opt.openAPIPath, openapi.SwaggerUIHandler(doc.Info.Title, srv.opt.openAPIPath+"/openapi.json"))
+	}
+
+	// expose grpc services with grpc-web, mounted before gateway and only take grpc-web and connect calls
+	if srv.opt.grpcWeb {
+		web, err := grpcweb.New(append([]grpcweb.OptionFunc{grpcweb.SetServer(srv.opt.grpcServer)}, srv.opt.grpcWebOptions...)...)
+		if err != nil {
+			panic(fmt.Errorf("rest server: %s", err))
+		}
+
+		srv.grpcWeb = web
+		if !web.OwnListener() {
+			rootPath.Use(web.Prefix(), web.Handler())
+		}
+	}
+
+	// expose grpc services with grpc-gateway, mounted last so it only receive unmatched routes under its prefix
+	if srv.opt.gateway {
+		h, ok := svc.GRPCHandler().(abstract.GatewayHandler)
+		if !ok {
+			panic(fmt.Errorf("rest server: grpc handler does not implement abstract.GatewayHandler"))
+		}
+
+		gw, err := gateway.New(h, append([]gateway.OptionFunc{gateway.SetServer(srv.opt.grpcServer)}, srv.opt.gatewayOptions...)...)
+		if err != nil {
+			panic(fmt.Errorf("rest server: %s", err))
+		}
+
+		srv.gateway = gw
+		if !gw.OwnListener() {
+			rootPath.Use(gw.Prefix(), gw.Handler())
+		}
+	}
+
 	// print all routes
 	for _, route := range srv.serverEngine.GetRoutes(true) {
 		if strings.EqualFold(route.Method, http.MethodHead) {
@@ -86,7 +199,34 @@ func New(svc factory.ServiceFactory, opts ...OptionFunc) factory.ApplicationFact
 }
 
 func (r *rest) Serve() {
-	err := r.serverEngine.Listen(r.opt.httpHost + ":" + r.opt.httpPort)
+	var err error
+
+	if r.gateway != nil && r.gateway.OwnListener() {
+		go func() {
+			if err := r.gateway.Serve(); err != nil {
+				logger.Red(fmt.Sprintf("GRPC gateway: %s", err))
+			}
+		}()
+	}
+
+	if r.grpcWeb != nil && r.grpcWeb.OwnListener() {
+		go func() {
+			if err := r.grpcWeb.Serve(); err != nil {
+				logger.Red(fmt.Sprintf("GRPC web: %s", err))
+			}
+		}()
+	}
+
+	addr := r.opt.httpHost + ":" + r.opt.httpPort
+
+	if r.opt.tlsConfig != nil {
+		var ln net.Listener
+		if ln, err = tls.Listen("tcp", addr, r.opt.tlsConfig); err == nil {
+			err = r.serverEngine.Listener(ln)
+		}
+	} else {
+		err = r.serverEngine.Listen(addr)
+	}
 
 	switch e := err.(type) {
 	case *net.OpError:
@@ -94,9 +234,60 @@ func (r *rest) Serve() {
 	}
 }
 
-func (r *rest) Shutdown(_ context.Context) {
+// Drain flip readiness to failing so load balancer stop sending traffic, requests are still served until Shutdown
+func (r *rest) Drain() time.Duration {
+	r.draining.Store(true)
+	return r.opt.shutdownDelay
+}
+
+// Shutdown drain the server and wait for pre-stop delay when it is not drained yet,
+// then shutdown with the deadline of context
+func (r *rest) Shutdown(ctx context.Context) {
 	defer logger.RedBold("Stopping REST Server")
-	_ = r.serverEngine.Shutdown()
+
+	if !r.draining.Swap(true) && r.opt.shutdownDelay > 0 {
+		logger.YellowBold(fmt.Sprintf("REST Server: draining, waiting %s before shutdown", r.opt.shutdownDelay))
+
+		select {
+		case <-time.After(r.opt.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	// close websocket and server-sent events, fiber shutdown does not wait hijacked connections.
+	// Realtime get half of remaining deadline so in-flight requests still have time to finish
+	if r.realtime != nil {
+		rtCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok {
+			rtCtx, cancel = context.WithTimeout(ctx, time.Until(deadline)/2)
+		}
+
+		r.realtime.Shutdown(rtCtx)
+		cancel()
+	}
+
+	if err := r.serverEngine.ShutdownWithContext(ctx); err != nil {
+		logger.Red(fmt.Sprintf("REST Server: shutdown %s, %d in-flight requests cut off", err, r.inFlight.Load()))
+	}
+
+	if r.gateway != nil {
+		r.gateway.Shutdown(ctx)
+	}
+
+	if r.grpcWeb != nil {
+		r.grpcWeb.Shutdown(ctx)
+	}
+
+	if r.certificate != nil {
+		r.certificate.Close()
+	}
+}
+
+func (r *rest) countInFlight(c *fiber.Ctx) error {
+	r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+
+	return c.Next()
 }
 
 func (r *rest) Name() string {
@@ -0,0 +1,117 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hellofresh/health-go/v4"
+	"github.com/vizucode/gokit/factory"
+	"github.com/vizucode/gokit/factory/server"
+	"github.com/vizucode/gokit/factory/server/rest"
+)
+
+func TestHealthCheckName(t *testing.T) {
+	tests := map[string][]health.Config{
+		"empty":     {{Check: func(context.Context) error { return nil }}},
+		"duplicate": {{Name: "db"}, {Name: "db"}},
+	}
+
+	for name, checks := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if err, _ := recover().(error); err == nil || !strings.HasPrefix(err.Error(), "rest server:") {
+					t.Fatalf("got %v, want rest server panic", err)
+				}
+			}()
+
+			rest.New(server.NewService(server.SetServiceName("test"), server.SetHealthChecks(checks...)))
+		})
+	}
+}
+
+func TestHealthCheckShutdownName(t *testing.T) {
+	// check named shutdown does not collide with check of draining server
+	rest.New(server.NewService(server.SetServiceName("test"), server.SetHealthChecks(health.Config{Name: "shutdown"})))
+}
+
+// newRest rest server with its engine to send request without listening
+func newRest(t *testing.T, opts ...rest.OptionFunc) (factory.ApplicationFactory, *fiber.App) {
+	t.Helper()
+
+	var engine *fiber.App
+	srv := rest.New(server.NewService(server.SetServiceName("test")),
+		append(opts, rest.SetEngineOption(func(app *fiber.App) { engine = app }))...)
+
+	return srv, engine
+}
+
+func readiness(t *testing.T, engine *fiber.App) int {
+	t.Helper()
+
+	res, err := engine.Test(httptest.NewRequest(http.MethodGet, "/live/status", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	return res.StatusCode
+}
+
+func TestShutdownDelay(t *testing.T) {
+	srv, engine := newRest(t, rest.SetShutdownDelay(200*time.Millisecond))
+
+	if sc := readiness(t, engine); sc != http.StatusOK {
+		t.Fatalf("readiness %d before shutdown", sc)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		srv.Shutdown(context.Background())
+		close(done)
+	}()
+
+	// readiness is failing during the delay while the server still serve request
+	for deadline := time.Now().Add(time.Second); readiness(t, engine) != http.StatusServiceUnavailable; {
+		if time.Now().After(deadline) {
+			t.Fatal("readiness is passing while draining")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case <-done:
+		t.Fatal("server stopped before shutdown delay")
+	default:
+	}
+
+	<-done
+}
+
+func TestShutdownDrained(t *testing.T) {
+	srv, engine := newRest(t, rest.SetShutdownDelay(time.Hour))
+
+	// delay is waited once by the caller of Drain, not again by Shutdown
+	if delay := srv.(factory.DrainingApplication).Drain(); delay != time.Hour {
+		t.Fatalf("drain delay %s", delay)
+	}
+	if sc := readiness(t, engine); sc != http.StatusServiceUnavailable {
+		t.Fatalf("readiness %d after drain", sc)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		srv.Shutdown(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown wait delay again after drain")
+	}
+}
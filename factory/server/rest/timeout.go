@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/utils/errorkit"
+	"github.com/vizucode/gokit/utils/request"
+)
+
+// HeaderRequestTimeout inbound and outbound remaining time budget of request in milliseconds
+const HeaderRequestTimeout = request.HeaderRequestTimeout
+
+// Timeout set deadline of request context for a route, the tighter deadline is used when
+// global timeout or inbound budget is shorter. Handler must pass c.UserContext() to downstream calls
+func Timeout(d time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return withDeadline(c, d)
+	}
+}
+
+// ErrorHandler render errorkit errors with errorkit.Envelope as json
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return c.Status(fe.Code).JSON(errorkit.Envelope{Message: fe.Message})
+	}
+
+	sc, envelope := errorkit.ToEnvelope(err)
+	return c.Status(sc).JSON(envelope)
+}
+
+func (r *rest) requestDeadline(c *fiber.Ctx) error {
+	return withDeadline(c, r.opt.requestTimeout)
+}
+
+func withDeadline(c *fiber.Ctx, d time.Duration) error {
+	if budget, ok := inboundBudget(c); ok && (d <= 0 || budget < d) {
+		d = budget
+	}
+
+	if d <= 0 {
+		return c.Next()
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), d)
+	defer cancel()
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) || (err == nil && c.Response().StatusCode() < http.StatusInternalServerError) {
+		return err
+	}
+
+	timeoutErr := fmt.Errorf("request exceeded deadline %s: %v", d, err)
+	logger.Log.Error(ctx, timeoutErr)
+
+	_, envelope := errorkit.ToEnvelope(errorkit.Error(timeoutErr, errorkit.Timeout, http.StatusGatewayTimeout))
+	return c.Status(http.StatusGatewayTimeout).JSON(envelope)
+}
+
+// inboundBudget remaining time budget from caller, X-Request-Timeout in milliseconds (or go duration)
+// or grpc-timeout from grpc-gateway / grpc-web caller
+func inboundBudget(c *fiber.Ctx) (time.Duration, bool) {
+	if v := c.Get(HeaderRequestTimeout); v != "" {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond, true
+		}
+
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d, true
+		}
+	}
+
+	if v := c.Get("grpc-timeout"); v != "" {
+		return request.ParseGrpcTimeout(v)
+	}
+
+	return 0, false
+}
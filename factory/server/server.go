@@ -71,10 +71,35 @@ func (s *server) shutdown(forceShutdown chan os.Signal) {
 	go func() {
 		defer close(done)
 
+		// drain all applications at once, so pre-stop delay is waited once instead of per application
+		var delay time.Duration
+		for _, srv := range s.service.GetApplications() {
+			if d, ok := srv.(factory.DrainingApplication); ok {
+				delay = max(delay, d.Drain())
+			}
+		}
+		if delay > 0 {
+			log.Printf("Draining, waiting %s before shutdown\n", delay)
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+		}
+
 		for _, srv := range s.service.GetApplications() {
 			srv.Shutdown(ctx)
 		}
 
+		// close resources after servers stop accepting requests which may still use them
+		if cs, ok := s.service.(factory.CloserService); ok {
+			for _, closer := range cs.Closers() {
+				if err := closer.Close(); err != nil {
+					log.Println(err)
+				}
+			}
+		}
+
 		done <- struct{}{}
 	}()
 
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vizucode/gokit/factory"
+)
+
+// drainingApp application recording when it is drained and shutdown
+type drainingApp struct {
+	name  string
+	delay time.Duration
+
+	mu       sync.Mutex
+	drained  time.Time
+	shutdown time.Time
+}
+
+func (a *drainingApp) Name() string { return a.name }
+
+func (a *drainingApp) Serve() {}
+
+func (a *drainingApp) Drain() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.drained = time.Now()
+	return a.delay
+}
+
+func (a *drainingApp) Shutdown(context.Context) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.shutdown = time.Now()
+}
+
+// appService service serving the given applications
+type appService struct {
+	factory.ServiceFactory
+	applications map[string]factory.ApplicationFactory
+}
+
+func (s appService) GetApplications() map[string]factory.ApplicationFactory {
+	return s.applications
+}
+
+func TestShutdownDelayOnce(t *testing.T) {
+	rest := &drainingApp{name: "rest", delay: 150 * time.Millisecond}
+	grpc := &drainingApp{name: "grpc", delay: 100 * time.Millisecond}
+
+	s := &server{service: appService{applications: map[string]factory.ApplicationFactory{"rest": rest, "grpc": grpc}}}
+
+	start := time.Now()
+	s.shutdown(make(chan os.Signal))
+	elapsed := time.Since(start)
+
+	// the longest delay is waited once, not the sum of delays of every application
+	if elapsed < 150*time.Millisecond || elapsed >= 250*time.Millisecond {
+		t.Fatalf("shutdown took %s, want the longest delay 150ms", elapsed)
+	}
+
+	for _, app := range []*drainingApp{rest, grpc} {
+		if app.drained.IsZero() || app.shutdown.Sub(app.drained) < 150*time.Millisecond {
+			t.Errorf("%s is shutdown %s after drained, before the delay", app.name, app.shutdown.Sub(app.drained))
+		}
+	}
+}
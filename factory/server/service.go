@@ -1,6 +1,9 @@
 package server
 
 import (
+	"io"
+
+	"github.com/hellofresh/health-go/v4"
 	"github.com/vizucode/gokit/abstract"
 	"github.com/vizucode/gokit/factory"
 	"github.com/vizucode/gokit/factory/server/grpc"
@@ -20,9 +23,12 @@ type service struct {
 	brokers              map[types.Broker]abstract.Broker
 	rest                 abstract.RestHandler
 	restOptions          []rest.OptionFunc
+	realtime             abstract.RealtimeHandler
 	grpc                 abstract.GRPCHandler
 	grpcOptions          []grpc.OptionFunc
 	applications         map[string]factory.ApplicationFactory
+	healthChecks         []health.Config
+	closers              []io.Closer
 }
 
 // SetServiceName setter
@@ -79,6 +85,13 @@ func SetRestHandlerOptions(opts ...rest.OptionFunc) ServiceFunc {
 	}
 }
 
+// SetRealtimeHandler setter websocket and server-sent events handler, served by rest server
+func SetRealtimeHandler(realtimeHandler abstract.RealtimeHandler) ServiceFunc {
+	return func(s *service) {
+		s.realtime = realtimeHandler
+	}
+}
+
 // SetGrpcHandler setter
 func SetGrpcHandler(grpcHandler abstract.GRPCHandler) ServiceFunc {
 	return func(s *service) {
@@ -93,6 +106,20 @@ func SetGrpcHandlerOptions(opts ...grpc.OptionFunc) ServiceFunc {
 	}
 }
 
+// SetHealthChecks setter dependency checks of rest and grpc health probes, e.g. database or cache ping
+func SetHealthChecks(checks ...health.Config) ServiceFunc {
+	return func(s *service) {
+		s.healthChecks = append(s.healthChecks, checks...)
+	}
+}
+
+// SetClosers setter resources closed after all applications are shutdown, e.g. grpc client factory
+func SetClosers(closers ...io.Closer) ServiceFunc {
+	return func(s *service) {
+		s.closers = append(s.closers, closers...)
+	}
+}
+
 // NewService initiate service
 func NewService(serviceFuncs ...ServiceFunc) factory.ServiceFactory {
 	svc := &service{}
@@ -119,16 +146,21 @@ func (s *service) GetApplications() map[string]factory.ApplicationFactory {
 		s.rest = defaultRestHandler()
 	}
 
-	// set rest handler into applications factory
-	if _, ok := s.applications[types.REST.String()]; !ok {
-		s.applications[types.REST.String()] = rest.New(s, s.restOptions...)
-	}
-
-	// set grpc handler into application factory
+	// set grpc handler into application factory, created before rest so gateway and grpc-web can reach it
+	restOptions := s.restOptions
 	if s.grpc != nil {
 		if _, ok := s.applications[types.GRPC.String()]; !ok {
 			s.applications[types.GRPC.String()] = grpc.New(s, s.grpcOptions...)
 		}
+
+		if srv, ok := s.applications[types.GRPC.String()].(grpc.Server); ok {
+			restOptions = append([]rest.OptionFunc{rest.SetGRPCServer(srv)}, restOptions...)
+		}
+	}
+
+	// set rest handler into applications factory
+	if _, ok := s.applications[types.REST.String()]; !ok {
+		s.applications[types.REST.String()] = rest.New(s, restOptions...)
 	}
 
 	// set rabbit-mq handler into applications factory
@@ -154,10 +186,22 @@ func (s *service) RESTHandler() abstract.RestHandler {
 	return s.rest
 }
 
+func (s *service) RealtimeHandler() abstract.RealtimeHandler {
+	return s.realtime
+}
+
 func (s *service) GRPCHandler() abstract.GRPCHandler {
 	return s.grpc
 }
 
+func (s *service) HealthChecks() []health.Config {
+	return s.healthChecks
+}
+
+func (s *service) Closers() []io.Closer {
+	return s.closers
+}
+
 func (s *service) BrokerHandler(broker types.Broker) abstract.BrokerHandler {
 	return s.brokerHandler[broker]
 }
@@ -1,6 +1,9 @@
 package factory
 
 import (
+	"io"
+
+	"github.com/hellofresh/health-go/v4"
 	"github.com/vizucode/gokit/abstract"
 	"github.com/vizucode/gokit/types"
 )
@@ -25,3 +28,21 @@ type ServiceFactory interface {
 	// GetBroker return abstraction of broker configuration by types.Broker
 	GetBroker(broker types.Broker) abstract.Broker
 }
+
+// RealtimeService optional interface of ServiceFactory serving websocket and server-sent events with rest server
+type RealtimeService interface {
+	// RealtimeHandler return abstraction of websocket and server-sent events handler
+	RealtimeHandler() abstract.RealtimeHandler
+}
+
+// HealthCheckService optional interface of ServiceFactory with dependency checks of health probes
+type HealthCheckService interface {
+	// HealthChecks return dependency checks shared by rest and grpc health probes
+	HealthChecks() []health.Config
+}
+
+// CloserService optional interface of ServiceFactory with resources closed on shutdown
+type CloserService interface {
+	// Closers return resources closed after all applications are shutdown, e.g. grpc client connections
+	Closers() []io.Closer
+}
@@ -46,6 +46,10 @@ func (d *DataLogger) Finalize(ctx context.Context) {
 		d.Device = i.(string)
 	}
 
+	if i, ok := value.LoadAndDelete(_Tags); ok && i != nil {
+		d.Tags = i.(map[string]interface{})
+	}
+
 	d.ExecTime = time.Since(d.TimeStart).Seconds()
 
 	appEnv := strings.ToUpper(env.GetString("APP_ENV"))
@@ -34,6 +34,7 @@ const (
 	_ErrorMessage Flags = "ErrorMessage"
 	_UserCode     Flags = "UserCode"
 	_Device       Flags = "Device"
+	_Tags         Flags = "Tags"
 	RequestId     Flags = "RequestId"
 	_SaltKey      Flags = "SaltKey"
 
@@ -46,24 +47,25 @@ const (
 
 // DataLogger is standard output to terminal
 type DataLogger struct {
-	RequestId     string       `json:"request_id"`
-	UserCode      string       `json:"user_code"`
-	Device        string       `json:"device"`
-	Ip            string       `json:"ip"`
-	Type          ServiceType  `json:"type"`
-	TimeStart     time.Time    `json:"time_start"`
-	Service       string       `json:"service"`
-	Host          string       `json:"host"`
-	Endpoint      string       `json:"endpoint"`
-	RequestMethod string       `json:"request_method"`
-	RequestHeader string       `json:"request_header"`
-	RequestBody   string       `json:"request_body"`
-	StatusCode    int          `json:"status_code"`
-	Response      interface{}  `json:"response"`
-	ErrorMessage  string       `json:"error_message"`
-	ExecTime      float64      `json:"exec_time"`
-	LogMessages   []LogMessage `json:"log_message"`
-	ThirdParties  []ThirdParty `json:"outgoing_log"`
+	RequestId     string                 `json:"request_id"`
+	UserCode      string                 `json:"user_code"`
+	Device        string                 `json:"device"`
+	Ip            string                 `json:"ip"`
+	Type          ServiceType            `json:"type"`
+	TimeStart     time.Time              `json:"time_start"`
+	Service       string                 `json:"service"`
+	Host          string                 `json:"host"`
+	Endpoint      string                 `json:"endpoint"`
+	RequestMethod string                 `json:"request_method"`
+	RequestHeader string                 `json:"request_header"`
+	RequestBody   string                 `json:"request_body"`
+	StatusCode    int                    `json:"status_code"`
+	Response      interface{}            `json:"response"`
+	ErrorMessage  string                 `json:"error_message"`
+	ExecTime      float64                `json:"exec_time"`
+	LogMessages   []LogMessage           `json:"log_message"`
+	ThirdParties  []ThirdParty           `json:"outgoing_log"`
+	Tags          map[string]interface{} `json:"tags,omitempty"`
 }
 
 // LogMessage is data logging for developer want to debug or error
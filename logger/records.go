@@ -89,6 +89,24 @@ func DeviceUser(ctx context.Context, userCode, deviceType, deviceOs, brand, mode
 	value.Set(_Device, device)
 }
 
+// Tag is record additional key value into data logger, e.g. cache status
+func Tag(ctx context.Context, key string, val interface{}) {
+	value, ok := extract(ctx)
+	if !ok {
+		return
+	}
+
+	var tags = make(map[string]interface{})
+	if tmp, ok := value.LoadAndDelete(_Tags); ok {
+		for k, v := range tmp.(map[string]interface{}) {
+			tags[k] = v
+		}
+	}
+	tags[key] = val
+
+	value.Set(_Tags, tags)
+}
+
 // Response is record data response to context
 func Response(ctx context.Context, status int, res interface{}, err error) {
 	value, ok := extract(ctx)
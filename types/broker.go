@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // Broker is the type returned by a classifier broker
 type Broker string
 
@@ -18,6 +20,34 @@ func (b Broker) String() string {
 	return string(b)
 }
 
+// ExchangeType type of exchange declared by consumer
+type ExchangeType string
+
+const (
+	// ExchangeDirect route message which routing key equal to binding key
+	ExchangeDirect ExchangeType = "direct"
+	// ExchangeTopic route message which routing key match binding pattern, e.g. order.*.created
+	ExchangeTopic ExchangeType = "topic"
+	// ExchangeFanout route message to every bound queue
+	ExchangeFanout ExchangeType = "fanout"
+	// ExchangeHeaders route message which headers match binding arguments
+	ExchangeHeaders ExchangeType = "headers"
+)
+
+func (e ExchangeType) String() string {
+	return string(e)
+}
+
+// QueueArguments optional arguments of queue declaration
+type QueueArguments struct {
+	MessageTTL           time.Duration          // expiration of message in queue, zero never expire
+	MaxLength            int                    // maximum ready messages, zero unlimited
+	Quorum               bool                   // replicated quorum queue, must be durable and not exclusive
+	DeadLetterExchange   string                 // exchange of rejected or expired message
+	DeadLetterRoutingKey string                 // routing key of dead-lettered message, empty keep original key
+	Extra                map[string]interface{} // other x-arguments of broker
+}
+
 // BrokerHandlerFunc type abstract for each broker implementation
 type BrokerHandlerFunc func(ec *EventContext) error
 
@@ -32,7 +62,15 @@ type BrokerHandler struct {
 	IsQueueExclusive bool   // queue exclusive
 	Channel          string // channel app name
 	IsAutoAck        bool   // auto acknowledgement
-	HandlerFunc      BrokerHandlerFunc
+	Prefetch         int    // unacknowledged deliveries of queue, zero follow worker option
+	Concurrency      int    // goroutines handling message of queue, zero follow worker option
+
+	ExchangeType ExchangeType           // type of declared exchange, default direct
+	BindingKeys  []string               // routing keys or patterns binding queue to exchange, default topic or queue name
+	BindingArgs  map[string]interface{} // binding arguments, e.g. x-match of headers exchange
+	QueueArgs    QueueArguments         // arguments of queue declaration
+
+	HandlerFunc BrokerHandlerFunc
 }
 
 // BrokerHandlerGroup group of broker handlers by topic, exchange, or queue with channels
@@ -98,3 +136,45 @@ func SetBrokerAutoAck(autoAck bool) BrokerHandlerOption {
 		bh.IsAutoAck = autoAck
 	}
 }
+
+// SetBrokerPrefetch set prefetch count of queue
+func SetBrokerPrefetch(prefetch int) BrokerHandlerOption {
+	return func(bh *BrokerHandler) {
+		bh.Prefetch = prefetch
+	}
+}
+
+// SetBrokerConcurrency set goroutines handling message of queue
+func SetBrokerConcurrency(concurrency int) BrokerHandlerOption {
+	return func(bh *BrokerHandler) {
+		bh.Concurrency = concurrency
+	}
+}
+
+// SetBrokerExchangeType set type of exchange
+func SetBrokerExchangeType(exchangeType ExchangeType) BrokerHandlerOption {
+	return func(bh *BrokerHandler) {
+		bh.ExchangeType = exchangeType
+	}
+}
+
+// SetBrokerBindingKeys set routing keys or patterns binding queue to exchange
+func SetBrokerBindingKeys(keys ...string) BrokerHandlerOption {
+	return func(bh *BrokerHandler) {
+		bh.BindingKeys = append(bh.BindingKeys, keys...)
+	}
+}
+
+// SetBrokerBindingArgs set binding arguments, e.g. x-match and matched headers of headers exchange
+func SetBrokerBindingArgs(args map[string]interface{}) BrokerHandlerOption {
+	return func(bh *BrokerHandler) {
+		bh.BindingArgs = args
+	}
+}
+
+// SetBrokerQueueArgs set arguments of queue declaration
+func SetBrokerQueueArgs(args QueueArguments) BrokerHandlerOption {
+	return func(bh *BrokerHandler) {
+		bh.QueueArgs = args
+	}
+}
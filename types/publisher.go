@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type PublisherArgument struct {
 	PriorityMessage int
 	CorrelationId   string
@@ -10,4 +12,8 @@ type PublisherArgument struct {
 	Key             string
 	Headers         map[string]interface{}
 	Message         []byte
+	// ContentType mime type of message, e.g. application/json
+	ContentType string
+	// TTL expiration of message in queue, zero never expire
+	TTL time.Duration
 }
@@ -0,0 +1,37 @@
+package backoff
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Exponential delay of attempt starting from zero, doubled every attempt from base and capped to max when max is set
+func Exponential(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	d := base
+	for i := 0; i < attempt; i++ {
+		if max > 0 && d >= max/2 {
+			return max
+		}
+		d *= 2
+	}
+
+	if max > 0 && d > max {
+		return max
+	}
+
+	return d
+}
+
+// Jitter randomize delay between half and full delay, spread reconnection of many instances
+func Jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
@@ -0,0 +1,33 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+		{5, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+
+	for _, c := range cases {
+		if got := Exponential(c.attempt, time.Second, 30*time.Second); got != c.want {
+			t.Errorf("attempt %d: got %s, want %s", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if got := Jitter(time.Second); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("jitter out of range: %s", got)
+		}
+	}
+}
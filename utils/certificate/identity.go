@@ -0,0 +1,53 @@
+package certificate
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+)
+
+type identityKey struct{}
+
+// Identity verified client certificate of mutual tls connection
+type Identity struct {
+	Subject      string   `json:"subject"`
+	CommonName   string   `json:"common_name"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	URIs         []string `json:"uris,omitempty"`
+	SerialNumber string   `json:"serial_number"`
+}
+
+// IdentityFromState get client identity from tls connection state, only verified certificate is accepted
+func IdentityFromState(state *tls.ConnectionState) (*Identity, bool) {
+	if state == nil || len(state.VerifiedChains) < 1 || len(state.VerifiedChains[0]) < 1 {
+		return nil, false
+	}
+
+	return identityFromCertificate(state.VerifiedChains[0][0]), true
+}
+
+func identityFromCertificate(cert *x509.Certificate) *Identity {
+	id := &Identity{
+		Subject:      cert.Subject.String(),
+		CommonName:   cert.Subject.CommonName,
+		DNSNames:     cert.DNSNames,
+		SerialNumber: cert.SerialNumber.String(),
+	}
+
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+	}
+
+	return id
+}
+
+// WithIdentity set client identity into context
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// GetIdentity get verified client identity from context
+func GetIdentity(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
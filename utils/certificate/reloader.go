@@ -0,0 +1,211 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vizucode/gokit/utils/env"
+)
+
+// OptionFunc setter reloader options
+type OptionFunc func(*option)
+
+type option struct {
+	// clientCAFile enable mutual tls, client certificate verified with the CA
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+	minVersion   uint16
+	// reloadInterval how often certificate files checked for changes
+	reloadInterval time.Duration
+}
+
+func defaultOption() option {
+	return option{
+		clientAuth:     tls.NoClientCert,
+		minVersion:     tls.VersionTLS12,
+		reloadInterval: env.GetDuration("TLS_RELOAD_INTERVAL", 30*time.Second),
+	}
+}
+
+// Reloader keep certificate and client CA up to date with the files on disk,
+// so rotated certificates are served without restarting the server
+type Reloader struct {
+	certFile string
+	keyFile  string
+	opt      option
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTime  time.Time
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewReloader load certificate and key files and start watching them for changes
+func NewReloader(certFile, keyFile string, opts ...OptionFunc) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		opt:      defaultOption(),
+		done:     make(chan struct{}),
+	}
+
+	for _, o := range opts {
+		o(&r.opt)
+	}
+
+	if r.opt.clientCAFile != "" && r.opt.clientAuth == tls.NoClientCert {
+		r.opt.clientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	go r.watch()
+	return r, nil
+}
+
+// TLSConfig return tls config which always serve the latest loaded certificate,
+// nextProtos is the ALPN protocols of the server, e.g. h2 for gRPC and http/1.1 for REST
+func (r *Reloader) TLSConfig(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: r.opt.minVersion,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return &tls.Config{
+				MinVersion:   r.opt.minVersion,
+				Certificates: []tls.Certificate{*r.cert},
+				ClientAuth:   r.opt.clientAuth,
+				ClientCAs:    r.clientCA,
+				NextProtos:   nextProtos,
+			}, nil
+		},
+	}
+}
+
+// Close stop watching certificate files
+func (r *Reloader) Close() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+}
+
+func (r *Reloader) watch() {
+	ticker := time.NewTicker(r.opt.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			if !r.isModified() {
+				continue
+			}
+
+			if err := r.load(); err != nil {
+				log.Printf("certificate: failed to reload %s, keep serving previous certificate: %s", r.certFile, err)
+				continue
+			}
+
+			log.Printf("certificate: %s reloaded", r.certFile)
+		}
+	}
+}
+
+func (r *Reloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.opt.clientCAFile != "" {
+		ca, err := os.ReadFile(r.opt.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client ca: %w", err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("client ca %s contains no certificate", r.opt.clientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTime = r.latestModTime()
+
+	return nil
+}
+
+func (r *Reloader) isModified() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.latestModTime().After(r.modTime)
+}
+
+// latestModTime follow symlinks, so secret volume mounted by kubernetes is detected as well
+func (r *Reloader) latestModTime() time.Time {
+	var latest time.Time
+
+	for _, file := range []string{r.certFile, r.keyFile, r.opt.clientCAFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest
+}
+
+// SetClientCA enable mutual tls, client certificate must be signed by the CA
+func SetClientCA(caFile string) OptionFunc {
+	return func(o *option) {
+		o.clientCAFile = caFile
+	}
+}
+
+// SetClientAuth set client authentication policy, default is tls.RequireAndVerifyClientCert when client CA is set
+func SetClientAuth(clientAuth tls.ClientAuthType) OptionFunc {
+	return func(o *option) {
+		o.clientAuth = clientAuth
+	}
+}
+
+// SetMinVersion set minimum tls version, default is tls 1.2
+func SetMinVersion(version uint16) OptionFunc {
+	return func(o *option) {
+		o.minVersion = version
+	}
+}
+
+// SetReloadInterval set how often certificate files checked for changes
+func SetReloadInterval(interval time.Duration) OptionFunc {
+	return func(o *option) {
+		o.reloadInterval = interval
+	}
+}
@@ -0,0 +1,235 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// issued certificate and its key in pem, signed by parent or self-signed when parent is nil
+type issued struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func issue(t *testing.T, commonName string, serial int64, isCA bool, parent *issued) *issued {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return &issued{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (i *issued) keyPair(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	pair, err := tls.X509KeyPair(i.certPEM, i.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pair
+}
+
+// writeFile write content with modification time moved forward, so the change is seen regardless of clock resolution
+func writeFile(t *testing.T, file string, content []byte, modTime time.Time) {
+	t.Helper()
+
+	if err := os.WriteFile(file, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// servedSerial serial number of certificate served to new connection
+func servedSerial(t *testing.T, r *Reloader) int64 {
+	t.Helper()
+
+	conf, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(conf.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert.SerialNumber.Int64()
+}
+
+// waitSerial wait until the served certificate has serial
+func waitSerial(t *testing.T, r *Reloader, serial int64) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); servedSerial(t, r) != serial; {
+		if time.Now().After(deadline) {
+			t.Fatalf("served certificate serial %d, want %d", servedSerial(t, r), serial)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReloaderReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	first := issue(t, "localhost", 1, false, nil)
+	writeFile(t, certFile, first.certPEM, time.Now().Add(-time.Minute))
+	writeFile(t, keyFile, first.keyPEM, time.Now().Add(-time.Minute))
+
+	r, err := NewReloader(certFile, keyFile, SetReloadInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if serial := servedSerial(t, r); serial != 1 {
+		t.Fatalf("served certificate serial %d, want 1", serial)
+	}
+
+	// rotated pair is served to new connection
+	second := issue(t, "localhost", 2, false, nil)
+	writeFile(t, certFile, second.certPEM, time.Now())
+	writeFile(t, keyFile, second.keyPEM, time.Now())
+	waitSerial(t, r, 2)
+
+	// invalid pair, e.g. certificate written before its key, keep serving the previous one
+	writeFile(t, keyFile, first.keyPEM, time.Now().Add(time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	if serial := servedSerial(t, r); serial != 2 {
+		t.Fatalf("served certificate serial %d after invalid pair, want 2", serial)
+	}
+
+	// pair is loaded once it is consistent again
+	third := issue(t, "localhost", 3, false, nil)
+	writeFile(t, certFile, third.certPEM, time.Now().Add(2*time.Minute))
+	writeFile(t, keyFile, third.keyPEM, time.Now().Add(2*time.Minute))
+	waitSerial(t, r, 3)
+}
+
+func TestReloaderInvalid(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	first, second := issue(t, "localhost", 1, false, nil), issue(t, "localhost", 2, false, nil)
+	writeFile(t, certFile, first.certPEM, time.Now())
+	writeFile(t, keyFile, second.keyPEM, time.Now())
+
+	if _, err := NewReloader(certFile, keyFile); err == nil {
+		t.Fatal("mismatched key pair is loaded")
+	}
+
+	writeFile(t, keyFile, first.keyPEM, time.Now())
+	if _, err := NewReloader(certFile, keyFile, SetClientCA(filepath.Join(dir, "missing.crt"))); err == nil {
+		t.Fatal("missing client ca is accepted")
+	}
+}
+
+// handshake complete tls handshake of client with conf, error is the one seen by server
+func handshake(t *testing.T, server *tls.Config, client *tls.Config) error {
+	t.Helper()
+
+	sc, cc := net.Pipe()
+	defer sc.Close()
+	defer cc.Close()
+
+	go func() {
+		conn := tls.Client(cc, client)
+		_ = conn.Handshake()
+		// read alert of rejected certificate sent after tls 1.3 handshake
+		_, _ = conn.Read(make([]byte, 1))
+	}()
+
+	conn := tls.Server(sc, server)
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	return conn.Handshake()
+}
+
+func TestReloaderClientCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+
+	ca := issue(t, "client ca", 1, true, nil)
+	serverCert := issue(t, "localhost", 2, false, ca)
+	writeFile(t, certFile, serverCert.certPEM, time.Now())
+	writeFile(t, keyFile, serverCert.keyPEM, time.Now())
+	writeFile(t, caFile, ca.certPEM, time.Now())
+
+	r, err := NewReloader(certFile, keyFile, SetClientCA(caFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	trusted := issue(t, "trusted", 3, false, ca)
+	untrusted := issue(t, "untrusted", 4, false, issue(t, "other ca", 5, true, nil))
+
+	tests := map[string]struct {
+		certificates []tls.Certificate
+		accepted     bool
+	}{
+		"signed by client ca": {[]tls.Certificate{trusted.keyPair(t)}, true},
+		"signed by other ca":  {[]tls.Certificate{untrusted.keyPair(t)}, false},
+		"without certificate": {nil, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := handshake(t, r.TLSConfig(), &tls.Config{ServerName: "localhost", RootCAs: roots, Certificates: tt.certificates})
+			if tt.accepted != (err == nil) {
+				t.Fatalf("accepted %t, got %v", tt.accepted, err)
+			}
+		})
+	}
+}
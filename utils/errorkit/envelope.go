@@ -0,0 +1,30 @@
+package errorkit
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Envelope standard body of error response
+type Envelope struct {
+	Code    string           `json:"code,omitempty"`
+	Message string           `json:"message"`
+	Errors  []FieldViolation `json:"errors,omitempty"`
+}
+
+// ToEnvelope convert error into http status code and error response body
+func ToEnvelope(err error) (int, Envelope) {
+	var (
+		errResponse *ErrorResponse
+		errStd      *ErrorStd
+	)
+
+	switch {
+	case errors.As(err, &errResponse):
+		return errResponse.StatusCode(), Envelope{Message: errResponse.ErrorMessage(), Errors: errResponse.Violations()}
+	case errors.As(err, &errStd):
+		return errStd.HttpStatusCode, Envelope{Code: errStd.ErrorCode(), Message: errStd.Message}
+	default:
+		return http.StatusInternalServerError, Envelope{Message: InternalServer}
+	}
+}
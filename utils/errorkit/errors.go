@@ -1,5 +1,10 @@
 package errorkit
 
+import (
+	"net/http"
+	"time"
+)
+
 const (
 	// Server Errors
 	InternalServer     = "Terjadi kesalahan pada server, silakan coba beberapa saat lagi"
@@ -14,6 +19,12 @@ const (
 	MethodNotAllowed    = "Metode HTTP yang digunakan tidak diizinkan untuk permintaan ini"
 	Conflict            = "Terjadi konflik saat memproses permintaan, silakan coba lagi"
 	UnprocessableEntity = "Entitas tidak dapat diproses, periksa data yang dikirim"
+	UnsupportedMedia    = "Format data yang dikirim tidak didukung"
+	NotAcceptable       = "Format respons yang diminta tidak didukung"
+
+	// Idempotency Errors
+	IdempotencyInProgress = "Permintaan yang sama sedang diproses, silakan coba beberapa saat lagi"
+	IdempotencyKeyReused  = "Idempotency-Key sudah digunakan untuk permintaan yang berbeda"
 
 	// Validation Errors
 	ValidationError    = "Data yang dikirim tidak valid"
@@ -40,6 +51,14 @@ type ErrorResponse struct {
 	err          error
 	errorMessage string
 	statusCode   int
+	violations   []FieldViolation
+	retryAfter   time.Duration
+}
+
+// FieldViolation invalid field of request
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
 }
 
 func Error(err error, errorMessage string, statusCode int) error {
@@ -50,6 +69,26 @@ func Error(err error, errorMessage string, statusCode int) error {
 	}
 }
 
+// BadRequestError validation error with invalid fields of request
+func BadRequestError(err error, violations ...FieldViolation) error {
+	return &ErrorResponse{
+		err:          err,
+		errorMessage: ValidationError,
+		statusCode:   http.StatusBadRequest,
+		violations:   violations,
+	}
+}
+
+// RetryableError error which can be retried by the caller after delay, e.g. rate limited
+func RetryableError(err error, errorMessage string, statusCode int, retryAfter time.Duration) error {
+	return &ErrorResponse{
+		err:          err,
+		errorMessage: errorMessage,
+		statusCode:   statusCode,
+		retryAfter:   retryAfter,
+	}
+}
+
 // Error is SystemError message
 func (er *ErrorResponse) Error() string {
 	return er.err.Error()
@@ -64,3 +103,18 @@ func (er *ErrorResponse) ErrorMessage() string {
 func (er *ErrorResponse) StatusCode() int {
 	return er.statusCode
 }
+
+// Violations invalid fields of request
+func (er *ErrorResponse) Violations() []FieldViolation {
+	return er.violations
+}
+
+// RetryAfter delay before the caller retry, zero when not retryable
+func (er *ErrorResponse) RetryAfter() time.Duration {
+	return er.retryAfter
+}
+
+// Unwrap original error
+func (er *ErrorResponse) Unwrap() error {
+	return er.err
+}
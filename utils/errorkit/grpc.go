@@ -0,0 +1,213 @@
+package errorkit
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ErrorDomain domain of errdetails.ErrorInfo created by errorkit
+const ErrorDomain = "errorkit"
+
+// ToStatus convert errorkit error into grpc status with error info, field violations and retry info.
+// Status error is returned as is, other error is converted with status.Convert
+func ToStatus(err error) *status.Status {
+	var (
+		errResponse *ErrorResponse
+		errStd      *ErrorStd
+	)
+
+	switch {
+	case errors.As(err, &errResponse):
+		st := status.New(errorCode(errResponse.StatusCode()), errResponse.ErrorMessage())
+		details := []protoadapt.MessageV1{errorInfo(errResponse.StatusCode(), nil)}
+
+		if len(errResponse.Violations()) > 0 {
+			br := &errdetails.BadRequest{}
+			for _, v := range errResponse.Violations() {
+				br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+					Field:       v.Field,
+					Description: v.Description,
+				})
+			}
+			details = append(details, br)
+		}
+
+		if errResponse.RetryAfter() > 0 {
+			details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(errResponse.RetryAfter())})
+		}
+
+		return withDetails(st, details...)
+	case errors.As(err, &errStd):
+		st := status.New(errorCode(errStd.HttpStatusCode), errStd.Message)
+		return withDetails(st, errorInfo(errStd.HttpStatusCode, map[string]string{
+			"rpc_status_code": errStd.RpcStatusCode,
+		}))
+	default:
+		return status.Convert(err)
+	}
+}
+
+// FromStatus convert grpc status error received by client into errorkit error, ErrorStd when the
+// status is created from ErrorStd, otherwise ErrorResponse. Non status error is returned as is
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.OK {
+		return err
+	}
+
+	var (
+		sc         = HTTPFromCode(st.Code())
+		message    = st.Message()
+		info       *errdetails.ErrorInfo
+		violations []FieldViolation
+		retry      *errdetails.RetryInfo
+	)
+
+	for _, d := range st.Details() {
+		switch detail := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = detail
+		case *errdetails.BadRequest:
+			for _, v := range detail.GetFieldViolations() {
+				violations = append(violations, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		case *errdetails.RetryInfo:
+			retry = detail
+		}
+	}
+
+	if info != nil && info.GetDomain() == ErrorDomain {
+		if v, err := strconv.Atoi(info.GetMetadata()["http_status"]); err == nil {
+			sc = v
+		}
+
+		if rpcStatusCode, ok := info.GetMetadata()["rpc_status_code"]; ok {
+			return NewErrorStd(sc, rpcStatusCode, message)
+		}
+	} else {
+		// message of foreign status is not user-facing
+		switch st.Code() {
+		case codes.Unknown, codes.Internal, codes.DataLoss:
+			message = InternalServer
+		case codes.Unavailable:
+			message = ServiceUnavailable
+		case codes.DeadlineExceeded:
+			message = Timeout
+		}
+	}
+
+	return &ErrorResponse{
+		err:          err,
+		errorMessage: message,
+		statusCode:   sc,
+		violations:   violations,
+		retryAfter:   retry.GetRetryDelay().AsDuration(),
+	}
+}
+
+// CodeFromHTTP convert http status code into grpc code
+func CodeFromHTTP(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	}
+
+	if statusCode >= 400 && statusCode < 500 {
+		return codes.FailedPrecondition
+	}
+
+	return codes.Internal
+}
+
+// HTTPFromCode convert grpc code into http status code
+func HTTPFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorCode grpc code of error, never OK so the error is not dropped
+func errorCode(statusCode int) codes.Code {
+	if code := CodeFromHTTP(statusCode); code != codes.OK {
+		return code
+	}
+
+	return codes.Unknown
+}
+
+func errorInfo(statusCode int, metadata map[string]string) *errdetails.ErrorInfo {
+	md := map[string]string{"http_status": strconv.Itoa(statusCode)}
+	for k, v := range metadata {
+		md[k] = v
+	}
+
+	return &errdetails.ErrorInfo{
+		Reason:   strings.ToUpper(strings.ReplaceAll(http.StatusText(statusCode), " ", "_")),
+		Domain:   ErrorDomain,
+		Metadata: md,
+	}
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) *status.Status {
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+
+	return withDetails
+}
@@ -0,0 +1,58 @@
+package errorkit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusRoundTrip(t *testing.T) {
+	err := BadRequestError(errors.New("name is empty"), FieldViolation{Field: "name", Description: "required"})
+
+	st := ToStatus(err)
+	if st.Code() != codes.InvalidArgument || st.Message() != ValidationError {
+		t.Fatalf("unexpected status %v", st)
+	}
+
+	var errResponse *ErrorResponse
+	if !errors.As(FromStatus(st.Err()), &errResponse) {
+		t.Fatalf("expected ErrorResponse")
+	}
+
+	if errResponse.StatusCode() != http.StatusBadRequest || errResponse.ErrorMessage() != ValidationError {
+		t.Errorf("unexpected error %d %s", errResponse.StatusCode(), errResponse.ErrorMessage())
+	}
+
+	if v := errResponse.Violations(); len(v) != 1 || v[0].Field != "name" {
+		t.Errorf("unexpected violations %+v", v)
+	}
+
+	retry := FromStatus(ToStatus(RetryableError(errors.New("limited"), Conflict, http.StatusTooManyRequests, time.Second)).Err())
+	if !errors.As(retry, &errResponse) || errResponse.RetryAfter() != time.Second || errResponse.StatusCode() != http.StatusTooManyRequests {
+		t.Errorf("unexpected retryable error %+v", retry)
+	}
+}
+
+func TestStatusRoundTripErrorStd(t *testing.T) {
+	err := FromStatus(ToStatus(NewErrorStd(http.StatusNotFound, "01", RecordNotFound)).Err())
+
+	var errStd *ErrorStd
+	if !errors.As(err, &errStd) {
+		t.Fatalf("expected ErrorStd, got %T", err)
+	}
+
+	if errStd.HttpStatusCode != http.StatusNotFound || errStd.RpcStatusCode != "01" || errStd.Message != RecordNotFound {
+		t.Errorf("unexpected error %+v", errStd)
+	}
+}
+
+func TestFromForeignStatus(t *testing.T) {
+	sc, envelope := ToEnvelope(FromStatus(status.Error(codes.Internal, "pq: connection refused")))
+	if sc != http.StatusInternalServerError || envelope.Message != InternalServer {
+		t.Errorf("internal message must not be exposed, got %d %s", sc, envelope.Message)
+	}
+}
@@ -14,6 +14,10 @@ var once sync.Once
 type metrics struct {
 	counter *prometheus.CounterVec
 	latency *prometheus.HistogramVec
+	panic   *prometheus.CounterVec
+	conn    *prometheus.GaugeVec
+	message *prometheus.CounterVec
+	broker  *prometheus.GaugeVec
 }
 
 var (
@@ -22,6 +26,14 @@ var (
 	reqsHelp    = "How many requests processed, partitioned by status code, method, path, and type."
 	latencyName = "request_duration_second"
 	latencyHelp = "How long it took to process the request, partitioned by status code, method, path, and type."
+	panicName   = "panic_total"
+	panicHelp   = "How many panics recovered, partitioned by transport and path."
+	connName    = "realtime_connections"
+	connHelp    = "How many realtime connections open, partitioned by transport and path."
+	messageName = "realtime_message_total"
+	messageHelp = "How many realtime messages processed, partitioned by transport, path, and direction."
+	brokerName  = "broker_connection_up"
+	brokerHelp  = "Whether connection to message broker is up, partitioned by broker and host."
 
 	DefaultBuckets = []float64{0.3, 1.2, 5.0}
 )
@@ -51,9 +63,53 @@ func NewPrometheus(serviceName string) {
 			return
 		}
 
+		panicCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Help:        panicHelp,
+			Name:        panicName,
+			ConstLabels: prometheus.Labels{"service": serviceName},
+		}, []string{"transport", "path", "type"})
+
+		if err := prometheus.Register(panicCounter); err != nil {
+			return
+		}
+
+		connGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Help:        connHelp,
+			Name:        connName,
+			ConstLabels: prometheus.Labels{"service": serviceName},
+		}, []string{"transport", "path", "type"})
+
+		if err := prometheus.Register(connGauge); err != nil {
+			return
+		}
+
+		messageCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Help:        messageHelp,
+			Name:        messageName,
+			ConstLabels: prometheus.Labels{"service": serviceName},
+		}, []string{"transport", "path", "direction", "type"})
+
+		if err := prometheus.Register(messageCounter); err != nil {
+			return
+		}
+
+		brokerGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Help:        brokerHelp,
+			Name:        brokerName,
+			ConstLabels: prometheus.Labels{"service": serviceName},
+		}, []string{"broker", "host"})
+
+		if err := prometheus.Register(brokerGauge); err != nil {
+			return
+		}
+
 		prom = &metrics{
 			counter: reqCounter,
 			latency: reqLatency,
+			panic:   panicCounter,
+			conn:    connGauge,
+			message: messageCounter,
+			broker:  brokerGauge,
 		}
 	})
 }
@@ -76,3 +132,40 @@ func PrometheusRecord(statusCode int, method, path, service string, duration tim
 	prom.counter.WithLabelValues(code, method, endpoint, service).Inc()
 	prom.latency.WithLabelValues(code, method, endpoint, service).Observe(float64(duration.Nanoseconds()) / 1000000000)
 }
+
+func PanicRecord(transport, path, service string) {
+	if prom == nil {
+		return
+	}
+
+	prom.panic.WithLabelValues(transport, path, service).Inc()
+}
+
+func RealtimeConnection(transport, path, service string, delta float64) {
+	if prom == nil {
+		return
+	}
+
+	prom.conn.WithLabelValues(transport, path, service).Add(delta)
+}
+
+func RealtimeMessage(transport, path, direction, service string) {
+	if prom == nil {
+		return
+	}
+
+	prom.message.WithLabelValues(transport, path, direction, service).Inc()
+}
+
+func BrokerConnection(broker, host string, up bool) {
+	if prom == nil {
+		return
+	}
+
+	var value float64
+	if up {
+		value = 1
+	}
+
+	prom.broker.WithLabelValues(broker, host).Set(value)
+}
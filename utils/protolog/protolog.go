@@ -0,0 +1,213 @@
+package protolog
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Redacted replacement of redacted string field
+const Redacted = "[REDACTED]"
+
+var marshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// Redactor render protobuf message as json for logging with redacted fields.
+// Field is redacted when it has option debug_redact or its path is listed, path is proto field names joined by dot
+// from root message, e.g. user.password, path without dot match the field name at any depth
+type Redactor struct {
+	paths map[string]struct{}
+	// scopes proper prefixes of dotted paths, field path outside scopes only match by field name
+	scopes map[string]struct{}
+	// cache whether message type need redaction, keyed by typeKey
+	cache sync.Map
+}
+
+// unscoped prefix of field path outside scopes
+const unscoped = "*"
+
+// typeKey message type walked under path prefix
+type typeKey struct {
+	md     protoreflect.MessageDescriptor
+	prefix string
+}
+
+// New create redactor with the given paths
+func New(paths ...string) *Redactor {
+	r := &Redactor{paths: make(map[string]struct{}, len(paths)), scopes: make(map[string]struct{})}
+	for _, p := range paths {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+
+		r.paths[p] = struct{}{}
+		names := strings.Split(p, ".")
+		for i := 1; i < len(names); i++ {
+			r.scopes[strings.Join(names[:i], ".")] = struct{}{}
+		}
+	}
+
+	return r
+}
+
+// Marshal render v as json, protobuf message with protojson and redacted fields, other value with encoding/json
+func (r *Redactor) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return json.Marshal(v)
+	}
+
+	if msg == nil || !msg.ProtoReflect().IsValid() {
+		return []byte("null"), nil
+	}
+
+	if r.needRedact(msg.ProtoReflect().Descriptor()) {
+		msg = proto.Clone(msg)
+		r.redact(msg.ProtoReflect(), "")
+	}
+
+	return marshaler.Marshal(msg)
+}
+
+// String render v as json string, empty when failed
+func (r *Redactor) String(v interface{}) string {
+	buf, err := r.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	return string(buf)
+}
+
+// Size wire size of protobuf message, length of json for other value
+func Size(v interface{}) int {
+	if msg, ok := v.(proto.Message); ok {
+		return proto.Size(msg)
+	}
+
+	buf, _ := json.Marshal(v)
+	return len(buf)
+}
+
+func (r *Redactor) redact(m protoreflect.Message, prefix string) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := join(prefix, string(fd.Name()))
+		if r.match(fd, path) {
+			redactField(m, fd, v)
+			return true
+		}
+
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				r.redact(list.Get(i).Message(), path)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				r.redact(mv.Message(), path)
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			r.redact(v.Message(), path)
+		}
+
+		return true
+	})
+}
+
+// needRedact whether the message type has redacted field, the result is cached per message type
+func (r *Redactor) needRedact(md protoreflect.MessageDescriptor) bool {
+	key := typeKey{md: md}
+	if need, ok := r.cache.Load(key); ok {
+		return need.(bool)
+	}
+
+	need := r.walk(md, "", make(map[typeKey]struct{}))
+	r.cache.Store(key, need)
+
+	return need
+}
+
+// walk message type for redacted field, each type is visited once per prefix so recursive type end.
+// Outside scopes only debug_redact and field name match, so the prefix is unscoped
+func (r *Redactor) walk(md protoreflect.MessageDescriptor, prefix string, visited map[typeKey]struct{}) bool {
+	key := typeKey{md: md, prefix: prefix}
+	if _, ok := visited[key]; ok {
+		return false
+	}
+	visited[key] = struct{}{}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := string(fd.Name())
+		if prefix != unscoped {
+			path = join(prefix, path)
+		}
+		if r.match(fd, path) {
+			return true
+		}
+
+		next := unscoped
+		if _, ok := r.scopes[path]; ok && prefix != unscoped {
+			next = path
+		}
+
+		child := fd.Message()
+		if fd.IsMap() {
+			child = fd.MapValue().Message()
+		}
+		if child != nil && r.walk(child, next, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Redactor) match(fd protoreflect.FieldDescriptor, path string) bool {
+	if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDebugRedact() {
+		return true
+	}
+
+	if _, ok := r.paths[path]; ok {
+		return true
+	}
+
+	_, ok := r.paths[string(fd.Name())]
+	return ok
+}
+
+// redactField replace string with Redacted, other kind of field is cleared
+func redactField(m protoreflect.Message, fd protoreflect.FieldDescriptor, v protoreflect.Value) {
+	switch {
+	case fd.IsList() && fd.Kind() == protoreflect.StringKind:
+		list := v.List()
+		for i := 0; i < list.Len(); i++ {
+			list.Set(i, protoreflect.ValueOfString(Redacted))
+		}
+	case fd.IsMap() && fd.MapValue().Kind() == protoreflect.StringKind:
+		mp := v.Map()
+		mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			mp.Set(k, protoreflect.ValueOfString(Redacted))
+			return true
+		})
+	case !fd.IsList() && !fd.IsMap() && fd.Kind() == protoreflect.StringKind:
+		m.Set(fd, protoreflect.ValueOfString(Redacted))
+	default:
+		m.Clear(fd)
+	}
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
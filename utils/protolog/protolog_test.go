@@ -0,0 +1,124 @@
+package protolog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func testMessage(t *testing.T) *dynamicpb.Message {
+	t.Helper()
+
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("protolog_test.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Card"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("number"), JsonName: proto.String("number"), Number: proto.Int32(1), Type: str, Label: optional},
+					{Name: proto.String("cvv"), JsonName: proto.String("cvv"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(), Label: optional},
+				},
+			},
+			{
+				Name: proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("name"), JsonName: proto.String("name"), Number: proto.Int32(1), Type: str, Label: optional},
+					{Name: proto.String("password"), JsonName: proto.String("password"), Number: proto.Int32(2), Type: str, Label: optional,
+						Options: &descriptorpb.FieldOptions{DebugRedact: proto.Bool(true)}},
+					{Name: proto.String("card"), JsonName: proto.String("card"), Number: proto.Int32(3), Label: optional,
+						Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".test.Card")},
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := dynamicpb.NewMessage(file.Messages().ByName("User"))
+	card := dynamicpb.NewMessage(file.Messages().ByName("Card"))
+	card.Set(card.Descriptor().Fields().ByName("number"), protoreflect.ValueOfString("4111111111111111"))
+	card.Set(card.Descriptor().Fields().ByName("cvv"), protoreflect.ValueOfInt32(123))
+
+	user.Set(user.Descriptor().Fields().ByName("name"), protoreflect.ValueOfString("budi"))
+	user.Set(user.Descriptor().Fields().ByName("password"), protoreflect.ValueOfString("secret"))
+	user.Set(user.Descriptor().Fields().ByName("card"), protoreflect.ValueOfMessage(card))
+
+	return user
+}
+
+func TestRedactorMarshal(t *testing.T) {
+	msg := testMessage(t)
+
+	buf, err := New("card.number", "cvv").Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+		Card     struct {
+			Number string `json:"number"`
+			Cvv    int    `json:"cvv"`
+		} `json:"card"`
+	}
+	if err = json.Unmarshal(buf, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Name != "budi" || got.Password != Redacted || got.Card.Number != Redacted || got.Card.Cvv != 0 {
+		t.Errorf("unexpected redacted message %s", buf)
+	}
+
+	// original message must not be modified
+	if password := msg.Get(msg.Descriptor().Fields().ByName("password")).String(); password != "secret" {
+		t.Errorf("original message is modified: %s", password)
+	}
+
+	if Size(msg) != proto.Size(msg) {
+		t.Errorf("size must be wire size of message")
+	}
+}
+
+func TestRedactorRecursiveType(t *testing.T) {
+	msg, err := structpb.NewStruct(map[string]interface{}{
+		"name":  "budi",
+		"items": []interface{}{map[string]interface{}{"token": "secret"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no path match, struct is walked through Value, ListValue and Struct again
+	r := New("card.number", "fields.items.list_value")
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if _, err = r.Marshal(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("marshal 100 struct took %s", elapsed)
+	}
+
+	buf, err := New("string_value").Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "secret") || strings.Contains(string(buf), "budi") {
+		t.Errorf("nested string is not redacted %s", buf)
+	}
+}
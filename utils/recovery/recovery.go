@@ -0,0 +1,81 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+
+	"github.com/vizucode/gokit/logger"
+	"github.com/vizucode/gokit/tracer"
+	"github.com/vizucode/gokit/utils/monitoring"
+)
+
+// Hook called on every recovered panic, e.g. to send alert
+type Hook func(ctx context.Context, p *Panic)
+
+var (
+	mu    sync.RWMutex
+	hooks []Hook
+)
+
+// Panic recovered panic with the stack trace
+type Panic struct {
+	Transport string
+	Service   string
+	Endpoint  string
+	Value     interface{}
+	Stack     string
+}
+
+// RegisterHook register hook called on every recovered panic of all transports
+func RegisterHook(h Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	hooks = append(hooks, h)
+}
+
+// New capture panic value and stack trace, must be called inside the deferred recover
+func New(transport, service, endpoint string, value interface{}) *Panic {
+	return &Panic{
+		Transport: transport,
+		Service:   service,
+		Endpoint:  endpoint,
+		Value:     value,
+		Stack:     string(debug.Stack()),
+	}
+}
+
+// Error panic message, never send it to the client
+func (p *Panic) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Record store stack trace into data logger and span, increment panic counter and call the hooks.
+// The panic itself is an error, caller set it as error of the span and logging
+func (p *Panic) Record(ctx context.Context, trace tracer.Tracer) {
+	logger.Tag(ctx, "panic_stack", p.Stack)
+
+	trace.SetTag("panic", true)
+	trace.SetTag("panic.stack", p.Stack)
+
+	monitoring.PanicRecord(p.Transport, p.Endpoint, p.Service)
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	// hooks run on their own goroutine so alerting never delay the response
+	for _, h := range hooks {
+		go func(h Hook) {
+			defer func() {
+				if re := recover(); re != nil {
+					log.Printf("recovery: panic hook panicked: %v", re)
+				}
+			}()
+
+			h(context.WithoutCancel(ctx), p)
+		}(h)
+	}
+}
@@ -0,0 +1,38 @@
+package request
+
+import (
+	"strconv"
+	"time"
+)
+
+// HeaderRequestTimeout remaining time budget of request in milliseconds, read by rest server
+// and sent to downstream service
+const HeaderRequestTimeout = "X-Request-Timeout"
+
+// ParseGrpcTimeout parse grpc-timeout header, e.g. 100m, 5S
+func ParseGrpcTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	units := map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+
+	unit, ok := units[v[len(v)-1]]
+	if !ok {
+		return 0, false
+	}
+
+	return time.Duration(n) * unit, true
+}
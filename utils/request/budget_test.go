@@ -0,0 +1,26 @@
+package request
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGrpcTimeout(t *testing.T) {
+	cases := map[string]time.Duration{
+		"100m": 100 * time.Millisecond,
+		"5S":   5 * time.Second,
+		"2H":   2 * time.Hour,
+		"10u":  10 * time.Microsecond,
+		"":     0,
+		"5":    0,
+		"5x":   0,
+		"-1S":  0,
+	}
+
+	for v, want := range cases {
+		got, ok := ParseGrpcTimeout(v)
+		if got != want || ok != (want > 0) {
+			t.Errorf("%q: got %s %v, want %s", v, got, ok, want)
+		}
+	}
+}
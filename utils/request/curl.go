@@ -2,18 +2,28 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-func (r *request) do(payload []byte, method string) ([]byte, int, error) {
-	req, err := http.NewRequest(method, r.url, buf(payload))
+func (r *request) do(ctx context.Context, payload []byte, method string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.url, buf(payload))
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
 
 	if r.header != nil {
-		req.Header = r.header
+		req.Header = r.header.Clone()
+	}
+
+	// propagate remaining deadline budget, so downstream service stop working when caller is gone
+	if deadline, ok := ctx.Deadline(); ok {
+		if budget := time.Until(deadline).Milliseconds(); budget > 0 {
+			req.Header.Set(HeaderRequestTimeout, strconv.FormatInt(budget, 10))
+		}
 	}
 
 	// set basic auth if exists
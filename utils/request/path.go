@@ -0,0 +1,22 @@
+package request
+
+import "strings"
+
+// TrimPathPrefix remove prefix of path at segment boundary, e.g. prefix /api match /api and /api/x but not /apiv1.
+// False when path is not under prefix
+func TrimPathPrefix(path, prefix string) (string, bool) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+
+	rest := path[len(prefix):]
+	switch {
+	case rest == "":
+		return "/", true
+	case rest[0] != '/':
+		return "", false
+	}
+
+	return rest, true
+}
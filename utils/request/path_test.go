@@ -0,0 +1,24 @@
+package request
+
+import "testing"
+
+func TestTrimPathPrefix(t *testing.T) {
+	cases := []struct {
+		path, prefix, want string
+		ok                 bool
+	}{
+		{"/api/v1/x", "/api", "/v1/x", true},
+		{"/api", "/api", "/", true},
+		{"/api/", "/api/", "/", true},
+		{"/apiv1/x", "/api", "", false},
+		{"/v1/x", "/api", "", false},
+		{"/v1/x", "", "/v1/x", true},
+	}
+
+	for _, c := range cases {
+		got, ok := TrimPathPrefix(c.path, c.prefix)
+		if got != c.want || ok != c.ok {
+			t.Errorf("%s with prefix %s: got %q %v, want %q %v", c.path, c.prefix, got, ok, c.want, c.ok)
+		}
+	}
+}
@@ -40,7 +40,7 @@ func (r *request) wrapper(ctx context.Context, payload []byte, method string) ([
 		trace.SetTag("request_body", tp.RequestBody)
 	}
 
-	res, status, err := r.do(payload, method)
+	res, status, err := r.do(ctx, payload, method)
 
 	trace.SetTag("response_status_code", status)
 